@@ -2,8 +2,11 @@ package plugin
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"runtime"
+	"time"
 
 	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/chrome"
 	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
@@ -17,6 +20,9 @@ import (
 
 const Name = "cloudeteer-pdfreport-app"
 
+// workerDrainTimeout is the time queued jobs have to finish, when the app is disposed.
+const workerDrainTimeout = 30 * time.Second
+
 // Make sure App implements required interfaces. This is important to do
 // since otherwise we will only get a not implemented error response from plugin in
 // runtime. Plugin should not implement all these interfaces - only those which are
@@ -71,21 +77,56 @@ func NewDashboardReporterApp(ctx context.Context, settings backend.AppInstanceSe
 		return nil, fmt.Errorf("error in httpclient new: %w", err)
 	}
 
-	// Create a new browser instance
+	// Create a new browser instance with a warm tab for each browser worker
 	var chromeInstance chrome.Instance
 
-	switch app.conf.RemoteChromeURL {
-	case "":
+	tabPool := chrome.TabPoolOptions{
+		Size:    app.conf.MaxBrowserWorkers,
+		MaxUses: app.conf.TabMaxUses,
+	}
+
+	if tabPool.Size <= 0 {
+		tabPool.Size = runtime.NumCPU()
+	}
+
+	switch remoteChromeURLs := app.conf.RemoteChromeURLs(); len(remoteChromeURLs) {
+	case 0:
+		localOptions := chrome.LocalOptions{
+			ExecPath:           app.conf.ChromeExecPath,
+			ProxyServer:        app.conf.ChromeProxyServer,
+			UserDataDir:        app.conf.ChromeUserDataDir,
+			Language:           app.conf.ChromeLanguage,
+			Headless:           app.conf.ChromeHeadless,
+			InsecureSkipVerify: app.conf.HTTPClientOptions.TLS.InsecureSkipVerify,
+		}
+
+		// Both are validated when loading the config
+		localOptions.Flags, _ = app.conf.ChromeFlagValues()
+		localOptions.WindowWidth, localOptions.WindowHeight, _ = app.conf.ChromeWindowDimensions()
+
 		chromeInstance, err = chrome.NewLocalBrowserInstance(
 			context.Background(), //nolint:contextcheck // context is cancelled after app instance is created.
 			app.ctxLogger,
-			app.conf.HTTPClientOptions.TLS.InsecureSkipVerify,
+			localOptions,
+			tabPool,
 		)
 	default:
+		remoteOptions := chrome.RemoteOptions{
+			URLs:       remoteChromeURLs,
+			Balancing:  app.conf.RemoteChromeBalancing,
+			Token:      app.conf.RemoteChromeToken,
+			TokenParam: app.conf.RemoteChromeTokenParam,
+		}
+
+		// Both are validated when loading the config
+		remoteOptions.Headers, _ = app.conf.RemoteChromeHeaderValues()
+		remoteOptions.TLSConfig, _ = app.conf.RemoteChromeTLSConfig()
+
 		chromeInstance, err = chrome.NewRemoteBrowserInstance(
 			context.Background(), //nolint:contextcheck // context is cancelled after app instance is created.
 			app.ctxLogger,
-			app.conf.RemoteChromeURL,
+			remoteOptions,
+			tabPool,
 		)
 	}
 
@@ -96,29 +137,42 @@ func NewDashboardReporterApp(ctx context.Context, settings backend.AppInstanceSe
 	// Use the same browser instance for all API requests
 	app.chromeInstance = chromeInstance
 
-	// Span Worker Pool across multiple instances
-	// Seems like context passed by App instance is closing channel at the end of
-	// request which I dont understand.
-	// So, discard context from the App. Always use background context and as we are
-	// safely disposing both workers and chrome instances in dispose() method, we are
-	// sure that there wont be any leaks.
+	// Span Worker Pool across multiple instances. The workers are stopped in the
+	// Dispose method, so there won't be any leaks.
 	app.workerPools = worker.Pools{
-		worker.Browser:  worker.New(context.Background(), app.conf.MaxBrowserWorkers),
-		worker.Renderer: worker.New(context.Background(), app.conf.MaxRenderWorkers),
+		worker.Browser:  worker.New(app.ctxLogger, worker.Browser, app.workerOptions(app.conf.MaxBrowserWorkers)),
+		worker.Renderer: worker.New(app.ctxLogger, worker.Renderer, app.workerOptions(app.conf.MaxRenderWorkers)),
 	}
 
 	return &app, nil
 }
 
+// workerOptions returns the options of a worker pool with the given number of workers.
+func (app *App) workerOptions(workers int) worker.Options {
+	return worker.Options{
+		Workers:          workers,
+		QueueSize:        app.conf.WorkerQueueSize,
+		MaxQueuedPerUser: app.conf.MaxQueuedJobsPerUser,
+		MaxPerUser:       app.conf.MaxJobsPerUser,
+		MaxPerOrg:        app.conf.MaxJobsPerOrg,
+	}
+}
+
 // Dispose here tells plugin SDK that plugin wants to clean up resources when a new instance
 // created.
 func (app *App) Dispose() {
 	// Clean up idle connections
 	app.httpClient.CloseIdleConnections()
 
+	// Let queued jobs finish before the browser is closed
 	if app.workerPools != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), workerDrainTimeout)
+		defer cancel()
+
 		for _, pool := range app.workerPools {
-			pool.Done()
+			if err := pool.Dispose(ctx); err != nil {
+				app.ctxLogger.Warn("failed to drain worker pool", "err", err)
+			}
 		}
 	}
 
@@ -132,9 +186,40 @@ func (app *App) Dispose() {
 }
 
 // CheckHealth handles health checks sent from Grafana to the plugin.
+// The state of the browser is reported, as reports cannot be generated while it restarts.
 func (app *App) CheckHealth(_ context.Context, _ *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	if app.chromeInstance == nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusOk,
+			Message: "ok",
+		}, nil
+	}
+
+	stats := app.chromeInstance.Stats()
+
+	details, err := json.Marshal(healthDetails{Stats: stats, Workers: app.workerPools.Stats()})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding browser stats: %w", err)
+	}
+
+	if !stats.Healthy {
+		return &backend.CheckHealthResult{
+			Status:      backend.HealthStatusError,
+			Message:     fmt.Sprintf("%s browser is not running (restarts: %d, last error: %s)", app.chromeInstance.Name(), stats.Restarts, stats.LastError),
+			JSONDetails: details,
+		}, nil
+	}
+
 	return &backend.CheckHealthResult{
-		Status:  backend.HealthStatusOk,
-		Message: "ok",
+		Status:      backend.HealthStatusOk,
+		Message:     fmt.Sprintf("ok (%s browser, restarts: %d)", app.chromeInstance.Name(), stats.Restarts),
+		JSONDetails: details,
 	}, nil
 }
+
+// healthDetails are the state of the browser and the load of the worker pools.
+type healthDetails struct {
+	chrome.Stats
+
+	Workers map[string]worker.Stats `json:"workers"`
+}
@@ -0,0 +1,313 @@
+package chrome
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"golang.org/x/net/context"
+)
+
+// Limits of the diagnostics of a tab, which keep pages that log in a loop from
+// exhausting the memory.
+const (
+	maxDiagnosticsEntries = 100
+	screenshotTimeout     = 5 * time.Second
+)
+
+// Diagnostics are the console messages, the JavaScript exceptions and the failed
+// requests of a tab along with a screenshot of the page. They help to find out why
+// a dashboard failed to load.
+type Diagnostics struct {
+	URL            string           `json:"url"`
+	Console        []ConsoleMessage `json:"console,omitempty"`
+	Exceptions     []string         `json:"exceptions,omitempty"`
+	FailedRequests []FailedRequest  `json:"failedRequests,omitempty"`
+	Error          string           `json:"error,omitempty"`
+
+	// Screenshot is the PNG image of the page when the tab was done.
+	Screenshot []byte `json:"-"`
+}
+
+// ConsoleMessage is a message logged to the console of the page.
+type ConsoleMessage struct {
+	Level string `json:"level"`
+	Text  string `json:"text"`
+}
+
+// FailedRequest is a request of the page that failed or returned an error status.
+type FailedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Status int64  `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// summary returns the most relevant diagnostics in a single line.
+func (d Diagnostics) summary() string {
+	lines := make([]string, 0, 3)
+
+	for _, exception := range d.Exceptions {
+		lines = append(lines, "exception: "+exception)
+	}
+
+	for _, request := range d.FailedRequests {
+		if request.Error != "" {
+			lines = append(lines, fmt.Sprintf("request %s %s failed: %s", request.Method, request.URL, request.Error))
+		} else {
+			lines = append(lines, fmt.Sprintf("request %s %s returned %d", request.Method, request.URL, request.Status))
+		}
+	}
+
+	for _, message := range d.Console {
+		if message.Level == "error" {
+			lines = append(lines, "console error: "+message.Text)
+		}
+	}
+
+	if len(lines) > 3 {
+		lines = append(lines[:3], fmt.Sprintf("%d more", len(lines)-3))
+	}
+
+	return strings.Join(lines, "; ")
+}
+
+// DiagnosticsError is an error of a tab along with the diagnostics of the tab.
+type DiagnosticsError struct {
+	Err         error
+	Diagnostics Diagnostics
+}
+
+func (e *DiagnosticsError) Error() string {
+	summary := e.Diagnostics.summary()
+	if summary == "" {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("%s (page %s: %s)", e.Err, e.Diagnostics.URL, summary)
+}
+
+func (e *DiagnosticsError) Unwrap() error {
+	return e.Err
+}
+
+// DiagnosticsLog collects the diagnostics of all tabs used for a report, e.g., for a
+// debug bundle.
+type DiagnosticsLog struct {
+	mu      sync.Mutex
+	entries []Diagnostics
+}
+
+// Add adds the diagnostics of a tab.
+func (l *DiagnosticsLog) Add(diagnostics Diagnostics) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries = append(l.entries, diagnostics)
+}
+
+// Entries returns the diagnostics of all tabs in the order the tabs were done.
+func (l *DiagnosticsLog) Entries() []Diagnostics {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]Diagnostics(nil), l.entries...)
+}
+
+type diagnosticsLogKey struct{}
+
+// WithDiagnosticsLog returns a context whose tabs add their diagnostics to the log.
+func WithDiagnosticsLog(ctx context.Context, log *DiagnosticsLog) context.Context {
+	return context.WithValue(ctx, diagnosticsLogKey{}, log)
+}
+
+// diagnosticsLogFromContext returns the log of the context or nil.
+func diagnosticsLogFromContext(ctx context.Context) *DiagnosticsLog {
+	log, _ := ctx.Value(diagnosticsLogKey{}).(*DiagnosticsLog)
+
+	return log
+}
+
+// diagnosticsRecorder records the diagnostics of a single use of a tab.
+type diagnosticsRecorder struct {
+	mu          sync.Mutex
+	diagnostics Diagnostics
+	requests    map[network.RequestID]*network.Request
+}
+
+// recordDiagnostics records the events of the tab until ctx is done.
+func recordDiagnostics(ctx context.Context) *diagnosticsRecorder {
+	r := &diagnosticsRecorder{requests: make(map[network.RequestID]*network.Request)}
+
+	chromedp.ListenTarget(ctx, func(event interface{}) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		switch event := event.(type) {
+		case *runtime.EventConsoleAPICalled:
+			if len(r.diagnostics.Console) < maxDiagnosticsEntries {
+				r.diagnostics.Console = append(r.diagnostics.Console, ConsoleMessage{
+					Level: string(event.Type),
+					Text:  formatArgs(event.Args),
+				})
+			}
+		case *runtime.EventExceptionThrown:
+			if len(r.diagnostics.Exceptions) < maxDiagnosticsEntries {
+				r.diagnostics.Exceptions = append(r.diagnostics.Exceptions, formatException(event.ExceptionDetails))
+			}
+		case *network.EventRequestWillBeSent:
+			r.requests[event.RequestID] = event.Request
+		case *network.EventResponseReceived:
+			// The request stays pending until its body is loaded.
+			request := r.requests[event.RequestID]
+
+			if request != nil && event.Response.Status >= 400 {
+				r.addFailedRequest(FailedRequest{Method: request.Method, URL: request.URL, Status: event.Response.Status})
+			}
+		case *network.EventLoadingFailed:
+			request := r.requests[event.RequestID]
+			delete(r.requests, event.RequestID)
+
+			// Blocked and canceled requests are expected, e.g., for the blocked URLs.
+			if request != nil && event.BlockedReason == "" && !event.Canceled {
+				r.addFailedRequest(FailedRequest{Method: request.Method, URL: request.URL, Error: event.ErrorText})
+			}
+		case *network.EventLoadingFinished:
+			delete(r.requests, event.RequestID)
+		}
+	})
+
+	return r
+}
+
+// addFailedRequest adds a failed request. The caller must hold the lock.
+func (r *diagnosticsRecorder) addFailedRequest(request FailedRequest) {
+	if len(r.diagnostics.FailedRequests) < maxDiagnosticsEntries {
+		r.diagnostics.FailedRequests = append(r.diagnostics.FailedRequests, request)
+	}
+}
+
+// pendingQueries returns the number of data source queries that are still loading.
+func (r *diagnosticsRecorder) pendingQueries() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var pending int
+
+	for _, request := range r.requests {
+		if strings.Contains(request.URL, "/api/ds/query") || strings.Contains(request.URL, "/api/datasources/proxy/") {
+			pending++
+		}
+	}
+
+	return pending
+}
+
+// snapshot returns a copy of the recorded diagnostics.
+func (r *diagnosticsRecorder) snapshot() Diagnostics {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	diagnostics := r.diagnostics
+	diagnostics.Console = append([]ConsoleMessage(nil), r.diagnostics.Console...)
+	diagnostics.Exceptions = append([]string(nil), r.diagnostics.Exceptions...)
+	diagnostics.FailedRequests = append([]FailedRequest(nil), r.diagnostics.FailedRequests...)
+
+	return diagnostics
+}
+
+// formatArgs formats the arguments of a console call like the console does.
+func formatArgs(args []*runtime.RemoteObject) string {
+	parts := make([]string, 0, len(args))
+
+	for _, arg := range args {
+		switch {
+		case arg.Value != nil:
+			var text string
+			if err := json.Unmarshal(arg.Value, &text); err != nil {
+				text = string(arg.Value)
+			}
+
+			parts = append(parts, text)
+		case arg.Description != "":
+			parts = append(parts, arg.Description)
+		default:
+			parts = append(parts, string(arg.Type))
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// formatException returns the message of an exception along with its location.
+func formatException(details *runtime.ExceptionDetails) string {
+	if details == nil {
+		return ""
+	}
+
+	message := details.Text
+	if details.Exception != nil && details.Exception.Description != "" {
+		message = details.Exception.Description
+	}
+
+	if details.URL != "" {
+		message = fmt.Sprintf("%s at %s:%d:%d", message, details.URL, details.LineNumber+1, details.ColumnNumber+1)
+	}
+
+	return message
+}
+
+// AttachDiagnostics attaches the diagnostics of the tab along with a screenshot of the
+// page to the error. If ctx has a diagnostics log, the diagnostics are added to the log
+// even if err is nil. Errors of canceled requests are returned as they are. It must be
+// called before the tab is closed.
+func (t *Tab) AttachDiagnostics(ctx context.Context, err error) error {
+	log := diagnosticsLogFromContext(ctx)
+
+	if t.diagnostics == nil || (err == nil && log == nil) || ctx.Err() != nil {
+		return err
+	}
+
+	diagnostics := t.diagnostics.snapshot()
+	diagnostics.Screenshot = t.screenshot(&diagnostics)
+
+	if err != nil {
+		diagnostics.Error = err.Error()
+	}
+
+	if log != nil {
+		log.Add(diagnostics)
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	return &DiagnosticsError{Err: err, Diagnostics: diagnostics}
+}
+
+// screenshot returns a screenshot of the page and sets the URL of the page. The tab
+// might have timed out already, so the screenshot gets a timeout of its own.
+func (t *Tab) screenshot(diagnostics *Diagnostics) []byte {
+	if t.pooled.ctx.Err() != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(t.pooled.ctx, screenshotTimeout)
+	defer cancel()
+
+	var screenshot []byte
+
+	// The page might be broken, so a missing screenshot is not an error.
+	_ = chromedp.Run(ctx,
+		chromedp.Location(&diagnostics.URL),
+		chromedp.CaptureScreenshot(&screenshot),
+	)
+
+	return screenshot
+}
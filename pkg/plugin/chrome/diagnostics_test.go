@@ -0,0 +1,51 @@
+package chrome_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/chrome"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTimeout = errors.New("timeout")
+
+func TestDiagnosticsError(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name        string
+		diagnostics chrome.Diagnostics
+		expected    string
+	}{
+		{
+			"without diagnostics",
+			chrome.Diagnostics{URL: "http://grafana/d/uid", Console: []chrome.ConsoleMessage{{Level: "log", Text: "loaded"}}},
+			"timeout",
+		},
+		{
+			"with diagnostics",
+			chrome.Diagnostics{
+				URL:            "http://grafana/d/uid",
+				Console:        []chrome.ConsoleMessage{{Level: "error", Text: "plugin not found"}},
+				Exceptions:     []string{"TypeError: x is undefined"},
+				FailedRequests: []chrome.FailedRequest{{Method: "GET", URL: "http://grafana/api/ds/query", Status: 502}},
+			},
+			"timeout (page http://grafana/d/uid: exception: TypeError: x is undefined; " +
+				"request GET http://grafana/api/ds/query returned 502; console error: plugin not found)",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := &chrome.DiagnosticsError{Err: errTimeout, Diagnostics: tc.diagnostics}
+			assert.Equal(t, tc.expected, err.Error())
+			require.ErrorIs(t, err, errTimeout)
+
+			var diagnosticsErr *chrome.DiagnosticsError
+			require.ErrorAs(t, errors.Join(err), &diagnosticsErr)
+			assert.Equal(t, tc.diagnostics.URL, diagnosticsErr.Diagnostics.URL)
+		})
+	}
+}
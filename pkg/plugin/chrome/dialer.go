@@ -0,0 +1,157 @@
+package chrome
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gobwas/ws"
+)
+
+// remoteDialers holds the websocket dialers of remote browsers by their address.
+var remoteDialers sync.Map
+
+// registration is a websocket dialer registered for the address of a remote browser.
+type registration struct {
+	dialer *ws.Dialer
+}
+
+// relayConn is the connection of chromedp to a remote browser, which is relayed to
+// the connection of the dialer of the browser.
+type relayConn struct {
+	net.Conn
+
+	// secure is set, if chromedp dialed a wss URL.
+	secure atomic.Bool
+}
+
+//nolint:gochecknoinits
+func init() {
+	// chromedp dials all browsers with the default dialer of the websocket package and
+	// has no option to set the dialer per browser. Hence, the default dialer relays the
+	// connections to remote browsers to the dialer registered for their address, which
+	// sends the headers and uses the TLS settings of the remote instance. Other browsers,
+	// like the local one, are dialed as usual.
+	ws.DefaultDialer.NetDial = netDial
+	ws.DefaultDialer.TLSClient = tlsClient
+}
+
+// registerDialer registers the dialer of the browser at wsURL until ctx is done.
+func registerDialer(ctx context.Context, wsURL string, dialer *ws.Dialer) {
+	addr, ok := dialAddr(wsURL)
+	if !ok {
+		return
+	}
+
+	r := &registration{dialer}
+	remoteDialers.Store(addr, r)
+
+	context.AfterFunc(ctx, func() { remoteDialers.CompareAndDelete(addr, r) })
+}
+
+// dialAddr returns the address dialed for the websocket URL.
+func dialAddr(wsURL string) (string, bool) {
+	u, err := url.Parse(wsURL)
+	if err != nil || u.Hostname() == "" {
+		return "", false
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "wss" {
+			port = "443"
+		}
+	}
+
+	return net.JoinHostPort(u.Hostname(), port), true
+}
+
+// netDial dials addr or, if a remote browser is registered for it, relays to its dialer.
+func netDial(ctx context.Context, network, addr string) (net.Conn, error) {
+	r, ok := remoteDialers.Load(addr)
+	if !ok {
+		var netDialer net.Dialer
+
+		return netDialer.DialContext(ctx, network, addr) //nolint:wrapcheck
+	}
+
+	client, server := net.Pipe()
+	conn := &relayConn{Conn: client}
+
+	go conn.relay(ctx, server, r.(*registration).dialer, addr) //nolint:forcetypeassert
+
+	return conn, nil
+}
+
+// tlsClient starts TLS over the connection like the default dialer does. Relayed
+// connections are encrypted by the dialer of the remote browser instead.
+func tlsClient(conn net.Conn, hostname string) net.Conn {
+	if relay, ok := conn.(*relayConn); ok {
+		relay.secure.Store(true)
+
+		return relay
+	}
+
+	return tls.Client(conn, &tls.Config{ServerName: hostname}) //nolint:gosec
+}
+
+// relay accepts the websocket handshake of chromedp on server, dials the requested URL
+// using dialer and copies the frames between both connections until either is closed.
+func (c *relayConn) relay(ctx context.Context, server net.Conn, dialer *ws.Dialer, addr string) {
+	defer server.Close()
+
+	var (
+		remote net.Conn
+		br     *bufio.Reader
+	)
+
+	upgrader := ws.Upgrader{
+		OnRequest: func(uri []byte) error {
+			scheme := "ws"
+			if c.secure.Load() {
+				scheme = "wss"
+			}
+
+			var err error
+			if remote, br, _, err = dialer.Dial(ctx, scheme+"://"+addr+string(uri)); err != nil {
+				return ws.RejectConnectionError(
+					ws.RejectionStatus(502),
+					ws.RejectionReason(fmt.Sprintf("error dialing remote browser: %v", err)),
+				)
+			}
+
+			return nil
+		},
+	}
+
+	if _, err := upgrader.Upgrade(server); err != nil {
+		if remote != nil {
+			remote.Close()
+		}
+
+		return
+	}
+
+	defer remote.Close()
+
+	// Frames sent by the browser right after the handshake might be buffered already.
+	var reader io.Reader = remote
+	if br != nil {
+		reader = io.MultiReader(br, remote)
+	}
+
+	go func() {
+		_, _ = io.Copy(remote, server)
+
+		remote.Close()
+	}()
+
+	_, _ = io.Copy(server, reader)
+}
@@ -0,0 +1,127 @@
+package chrome
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// RemoteOptions configures the connections to remote browsers.
+type RemoteOptions struct {
+	// URLs of the remote browsers, either websocket debugger URLs or URLs like
+	// http://host:9222, whose debugger URL is discovered.
+	URLs []string
+	// Balancing is the strategy to spread tabs across the browsers.
+	Balancing string
+
+	// Token authenticates the plugin at the browsers. It is sent as query param
+	// TokenParam or, if TokenParam is empty, as bearer token.
+	Token      string
+	TokenParam string
+	// Headers are sent along with every connection.
+	Headers http.Header
+	// TLSConfig is used for https and wss connections.
+	TLSConfig *tls.Config
+}
+
+// header returns the headers of the connections including the token.
+func (o RemoteOptions) header() http.Header {
+	header := o.Headers.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	if o.Token != "" && o.TokenParam == "" {
+		header.Set("Authorization", "Bearer "+o.Token)
+	}
+
+	return header
+}
+
+// withToken returns the URL with the token as query param, if configured.
+func (o RemoteOptions) withToken(u *url.URL) *url.URL {
+	if o.Token == "" || o.TokenParam == "" {
+		return u
+	}
+
+	query := u.Query()
+	query.Set(o.TokenParam, o.Token)
+	u.RawQuery = query.Encode()
+
+	return u
+}
+
+// debuggerURL returns the websocket debugger URL of a remote browser. URLs that do
+// not point to a debugger, like http://host:9222, are resolved via /json/version of
+// the browser. As browsers report their own host, which is usually not reachable,
+// only the path of the discovered URL is used.
+func (o RemoteOptions) debuggerURL(ctx context.Context, remoteURL string) (string, error) {
+	u, err := url.Parse(remoteURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid remote chrome URL: %w", err)
+	}
+
+	secure := u.Scheme == "https" || u.Scheme == "wss"
+
+	wsScheme, httpScheme := "ws", "http"
+	if secure {
+		wsScheme, httpScheme = "wss", "https"
+	}
+
+	if strings.Contains(u.Path, "/devtools/browser/") {
+		u.Scheme = wsScheme
+
+		return o.withToken(u).String(), nil
+	}
+
+	versionURL := *u
+	versionURL.Scheme = httpScheme
+	versionURL.Path = "/json/version"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.withToken(&versionURL).String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating discovery request: %w", err)
+	}
+
+	req.Header = o.header()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: o.TLSConfig}}
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error discovering debugger URL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error discovering debugger URL: status code is %d", resp.StatusCode)
+	}
+
+	var version struct {
+		WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(&version); err != nil {
+		return "", fmt.Errorf("error decoding %s: %w", versionURL.Path, err)
+	}
+
+	discovered, err := url.Parse(version.WebSocketDebuggerURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid debugger URL %q: %w", version.WebSocketDebuggerURL, err)
+	}
+
+	if discovered.Path == "" {
+		return "", fmt.Errorf("%w: %q", ErrNoDebuggerURL, version.WebSocketDebuggerURL)
+	}
+
+	u.Scheme = wsScheme
+	u.Path = discovered.Path
+
+	return o.withToken(u).String(), nil
+}
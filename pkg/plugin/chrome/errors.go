@@ -0,0 +1,10 @@
+package chrome
+
+import "errors"
+
+var (
+	ErrBrowserCrashed = errors.New("browser crashed")
+	ErrBrowserClosed  = errors.New("browser instance closed")
+	ErrNoDebuggerURL  = errors.New("browser did not report a debugger URL")
+	ErrPageNotReady   = errors.New("page did not finish loading")
+)
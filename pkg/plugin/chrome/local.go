@@ -1,22 +1,46 @@
 package chrome
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/chromedp/cdproto/browser"
 	"github.com/chromedp/chromedp"
 	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 	"golang.org/x/net/context"
 )
 
-// LocalInstance is a locally running browser instance.
-type LocalInstance struct {
-	allocCtx   context.Context
-	browserCtx context.Context
+// Backoff between attempts to restart a crashed browser.
+const (
+	restartBackoffMin = 1 * time.Second
+	restartBackoffMax = 30 * time.Second
+)
+
+// LocalOptions configures the locally running browser.
+type LocalOptions struct {
+	// ExecPath is the path of the browser executable. If empty, the browser is
+	// looked up in the PATH.
+	ExecPath string
+	// Flags are extra command line flags. Values true and false switch flags on or off.
+	Flags       map[string]any
+	ProxyServer string
+	// UserDataDir is the profile directory. If empty, a temporary one is used.
+	UserDataDir  string
+	WindowWidth  int
+	WindowHeight int
+	// Language sets the UI language and the Accept-Language header of the browser.
+	Language string
+	Headless bool
+	// InsecureSkipVerify ignores certificate errors of the visited pages.
+	InsecureSkipVerify bool
 }
 
-// NewLocalBrowserInstance creates a new local browser instance.
-func NewLocalBrowserInstance(ctx context.Context, logger log.Logger, insecureSkipVerify bool) (*LocalInstance, error) {
+// allocatorOptions returns the options to start the browser with.
+func (o LocalOptions) allocatorOptions() []chromedp.ExecAllocatorOption {
 	// go-staticcheck was keep complaining about unused var
 	// preallocate options
 	// chromeOptions := make([]func(*chromedp.ExecAllocator), 0, len(chromedp.DefaultExecAllocatorOptions)+3)
@@ -26,7 +50,34 @@ func NewLocalBrowserInstance(ctx context.Context, logger log.Logger, insecureSki
 		chromedp.DisableGPU,
 	)
 
-	if insecureSkipVerify {
+	if !o.Headless {
+		chromeOptions = append(chromeOptions, chromedp.Flag("headless", false))
+	}
+
+	if o.ExecPath != "" {
+		chromeOptions = append(chromeOptions, chromedp.ExecPath(o.ExecPath))
+	}
+
+	if o.ProxyServer != "" {
+		chromeOptions = append(chromeOptions, chromedp.ProxyServer(o.ProxyServer))
+	}
+
+	if o.UserDataDir != "" {
+		chromeOptions = append(chromeOptions, chromedp.UserDataDir(o.UserDataDir))
+	}
+
+	if o.WindowWidth > 0 && o.WindowHeight > 0 {
+		chromeOptions = append(chromeOptions, chromedp.WindowSize(o.WindowWidth, o.WindowHeight))
+	}
+
+	if o.Language != "" {
+		chromeOptions = append(chromeOptions,
+			chromedp.Flag("lang", o.Language),
+			chromedp.Flag("accept-lang", o.Language),
+		)
+	}
+
+	if o.InsecureSkipVerify {
 		// Seems like this is critical. When it is not turned on there are no errors
 		// and plugin will exit without rendering any panels. Not sure why the error
 		// handling is failing here. So, add this option as default just to avoid
@@ -36,6 +87,62 @@ func NewLocalBrowserInstance(ctx context.Context, logger log.Logger, insecureSki
 		chromeOptions = append(chromeOptions, chromedp.Flag("ignore-certificate-errors", "1"))
 	}
 
+	// Extra flags come last to be able to override all of the above.
+	for name, value := range o.Flags {
+		chromeOptions = append(chromeOptions, chromedp.Flag(name, value))
+	}
+
+	return chromeOptions
+}
+
+// LocalInstance is a locally running browser instance. The browser is supervised
+// and restarted when it crashes or gets killed.
+type LocalInstance struct {
+	logger  log.Logger
+	options []chromedp.ExecAllocatorOption
+
+	mu          sync.RWMutex
+	allocCancel context.CancelFunc
+	browserCtx  context.Context
+	stats       Stats
+
+	tabs *tabPool
+
+	// ready is closed when the browser is running and replaced when it crashed
+	ready chan struct{}
+
+	// done is closed when the instance is closed
+	done chan struct{}
+}
+
+// NewLocalBrowserInstance creates a new local browser instance. The browser is
+// started right away, so that an invalid configuration fails early.
+func NewLocalBrowserInstance(ctx context.Context, logger log.Logger, options LocalOptions, tabPool TabPoolOptions) (*LocalInstance, error) {
+	i := &LocalInstance{
+		logger:  logger.With("subsystem", "chromium"),
+		options: options.allocatorOptions(),
+		ready:   make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	i.tabs = newTabPool(i.logger, tabPool, func() context.Context {
+		i.mu.RLock()
+		defer i.mu.RUnlock()
+
+		return i.browserCtx
+	})
+
+	if err := i.start(ctx); err != nil {
+		return nil, err
+	}
+
+	go i.supervise(ctx)
+
+	return i, nil
+}
+
+// start starts a new browser process along with an empty tab.
+func (i *LocalInstance) start(ctx context.Context) error {
 	// Create a new browser allocator
 	/*
 		The side-effect here is everytime the settings are updated from Grafana UI
@@ -51,27 +158,146 @@ func NewLocalBrowserInstance(ctx context.Context, logger log.Logger, insecureSki
 		it is not normal that these will be updated regularly. So, we can live with
 		this side-effect without running into deep issues.
 	*/
-	allocCtx, _ := chromedp.NewExecAllocator(ctx, chromeOptions...)
+	allocCtx, allocCancel := chromedp.NewExecAllocator(ctx, i.options...)
 
 	// start a browser (and an empty tab) so we can add more tabs to the browser
-	chromeLogger := logger.With("subsystem", "chromium")
 	browserCtx, _ := chromedp.NewContext(allocCtx,
-		chromedp.WithErrorf(func(s string, i ...interface{}) {
-			chromeLogger.Error(fmt.Sprintf(s, i...))
+		chromedp.WithErrorf(func(s string, args ...interface{}) {
+			i.logger.Error(fmt.Sprintf(s, args...))
 		}),
-		chromedp.WithLogf(func(s string, i ...interface{}) {
-			chromeLogger.Debug(fmt.Sprintf(s, i...))
+		chromedp.WithLogf(func(s string, args ...interface{}) {
+			i.logger.Debug(fmt.Sprintf(s, args...))
 		}),
 	)
 
 	if err := chromedp.Run(browserCtx); err != nil {
-		return nil, fmt.Errorf("couldn't create browser context: %w", err)
+		allocCancel()
+
+		return fmt.Errorf("couldn't create browser context: %w", err)
 	}
 
-	return &LocalInstance{
-		allocCtx,
-		browserCtx,
-	}, nil
+	i.logVersion(browserCtx)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	// The instance might have been closed while the browser was restarting.
+	select {
+	case <-i.done:
+		allocCancel()
+
+		return ErrBrowserClosed
+	default:
+	}
+
+	i.allocCancel, i.browserCtx = allocCancel, browserCtx
+	i.stats.Healthy = true
+
+	close(i.ready)
+	browserUp.Set(1)
+
+	go i.tabs.warm()
+
+	return nil
+}
+
+// logVersion logs the version and the command line of the started browser.
+func (i *LocalInstance) logVersion(browserCtx context.Context) {
+	var (
+		product string
+		args    []string
+	)
+
+	err := chromedp.Run(browserCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+
+		if _, product, _, _, _, err = browser.GetVersion().Do(ctx); err != nil { //nolint:dogsled
+			return err //nolint:wrapcheck
+		}
+
+		// The command line is only available with the enable-automation flag.
+		args, err = browser.GetBrowserCommandLine().Do(ctx)
+		if err != nil {
+			i.logger.Debug("failed to get browser command line", "err", err)
+		}
+
+		return nil
+	}))
+	if err != nil {
+		i.logger.Warn("failed to get browser version", "err", err)
+
+		return
+	}
+
+	i.logger.Info("started local browser", "version", product, "flags", strings.Join(args, " "))
+}
+
+// supervise waits for the browser to exit and restarts it with backoff, unless the
+// instance is closed.
+func (i *LocalInstance) supervise(ctx context.Context) {
+	for {
+		i.mu.RLock()
+		browserCtx, allocCancel := i.browserCtx, i.allocCancel
+		i.mu.RUnlock()
+
+		select {
+		case <-i.done:
+			return
+		case <-browserCtx.Done():
+		}
+
+		// Close cancels the browser after closing done, both may be ready at once.
+		select {
+		case <-i.done:
+			return
+		default:
+		}
+
+		i.logger.Error("browser exited unexpectedly, restarting it")
+
+		// Release the resources of the crashed browser, like its user data directory.
+		i.tabs.drain()
+		allocCancel()
+
+		i.mu.Lock()
+		i.stats.Healthy = false
+		i.ready = make(chan struct{})
+		i.mu.Unlock()
+
+		browserUp.Set(0)
+
+		for backoff := restartBackoffMin; ; backoff = min(backoff*2, restartBackoffMax) {
+			err := i.start(ctx)
+			if err == nil {
+				break
+			}
+
+			if errors.Is(err, ErrBrowserClosed) {
+				return
+			}
+
+			i.logger.Error("failed to restart browser", "err", err, "retry_in", backoff)
+
+			i.mu.Lock()
+			i.stats.LastError = err.Error()
+			i.mu.Unlock()
+
+			select {
+			case <-i.done:
+				return
+			case <-time.After(backoff):
+			}
+		}
+
+		i.mu.Lock()
+		i.stats.Restarts++
+		i.stats.LastRestart = time.Now()
+		restarts := i.stats.Restarts
+		i.mu.Unlock()
+
+		browserRestarts.Inc()
+		i.logger.Info("browser restarted", "restarts", restarts)
+	}
 }
 
 // Name returns the kind of browser instance.
@@ -79,25 +305,71 @@ func (i *LocalInstance) Name() string {
 	return "local"
 }
 
-// NewTab starts and returns a new tab on current browser instance.
-func (i *LocalInstance) NewTab(_ log.Logger, _ config.Config) *Tab {
-	ctx, _ := chromedp.NewContext(i.browserCtx)
+// NewTab returns a warm tab of the pool or starts a new tab on current browser instance.
+func (i *LocalInstance) NewTab(ctx context.Context, _ log.Logger, _ config.Config) *Tab {
+	return i.tabs.tab(ctx, i.done)
+}
+
+// Stats returns the state and restarts of the browser.
+func (i *LocalInstance) Stats() Stats {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return i.stats
+}
+
+// WaitHealthy blocks until the browser is running.
+func (i *LocalInstance) WaitHealthy(ctx context.Context) error {
+	for {
+		i.mu.RLock()
+		ready, browserCtx := i.ready, i.browserCtx
+		i.mu.RUnlock()
+
+		// The browser might have crashed without the supervisor having noticed it yet.
+		poll := time.After(100 * time.Millisecond)
 
-	return &Tab{
-		ctx: ctx,
+		select {
+		case <-ready:
+			if browserCtx.Err() == nil {
+				return nil
+			}
+		case <-poll:
+		case <-i.done:
+			return ErrBrowserClosed
+		case <-ctx.Done():
+			return fmt.Errorf("error waiting for browser restart: %w", ctx.Err())
+		}
+
+		select {
+		case <-poll:
+		case <-i.done:
+			return ErrBrowserClosed
+		case <-ctx.Done():
+			return fmt.Errorf("error waiting for browser restart: %w", ctx.Err())
+		}
 	}
 }
 
 func (i *LocalInstance) Close(logger log.Logger) {
+	i.tabs.close()
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	select {
+	case <-i.done:
+		return
+	default:
+		close(i.done)
+	}
+
 	if i.browserCtx != nil {
 		if err := chromedp.Cancel(i.browserCtx); err != nil {
 			logger.Error("got error from cancel browser context", "error", err)
 		}
 	}
 
-	if i.allocCtx != nil {
-		if err := chromedp.Cancel(i.allocCtx); err != nil {
-			logger.Error("got error from cancel browser allocator context", "error", err)
-		}
+	if i.allocCancel != nil {
+		i.allocCancel()
 	}
 }
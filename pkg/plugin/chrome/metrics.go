@@ -0,0 +1,33 @@
+package chrome
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics of the browser supervision, collected by Grafana along with the plugin metrics.
+var (
+	browserRestarts = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "grafana_plugin",
+		Name:      "reporter_browser_restarts_total",
+		Help:      "Number of restarts of the local browser after it crashed.",
+	})
+
+	browserUp = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "grafana_plugin",
+		Name:      "reporter_browser_up",
+		Help:      "Whether the local browser is running (1) or restarting (0).",
+	})
+
+	tabsCanceled = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "grafana_plugin",
+		Name:      "reporter_tabs_canceled_total",
+		Help:      "Number of browser tabs whose request was canceled while they were in use.",
+	})
+
+	remoteBrowserUp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana_plugin",
+		Name:      "reporter_remote_browser_up",
+		Help:      "Whether a remote browser passed its health check (1) or was ejected (0).",
+	}, []string{"endpoint"})
+)
@@ -0,0 +1,263 @@
+package chrome
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/storage"
+	"github.com/chromedp/chromedp"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// Timeout for preparing a tab for its next use.
+const tabResetTimeout = 5 * time.Second
+
+// TabPoolOptions configures the pool of warm tabs of a browser instance.
+type TabPoolOptions struct {
+	// Size is the maximum number of idle tabs kept in the pool.
+	Size int
+	// MaxUses is the number of uses after which a tab is replaced by a new one.
+	// If it is 0, tabs are not reused.
+	MaxUses int
+}
+
+// pooledTab is a browser tab with its own browser context, which isolates its
+// cookies and storage from the other tabs. Its context is done when the tab or
+// its browser exited.
+type pooledTab struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	uses   int
+}
+
+// tabPool keeps pre-created tabs of a browser to avoid the latency of creating
+// a tab for every use.
+type tabPool struct {
+	logger  log.Logger
+	options TabPoolOptions
+
+	// browser returns the context of the running browser
+	browser func() context.Context
+	// contextOptions are the options of the tab contexts
+	contextOptions []chromedp.ContextOption
+
+	mu     sync.Mutex
+	tabs   []*pooledTab
+	closed bool
+
+	// busy is the number of tabs in use
+	busy atomic.Int64
+}
+
+// newTabPool returns a new tab pool for the browser.
+func newTabPool(logger log.Logger, options TabPoolOptions, browser func() context.Context,
+	contextOptions ...chromedp.ContextOption,
+) *tabPool {
+	return &tabPool{
+		logger:         logger,
+		options:        options,
+		browser:        browser,
+		contextOptions: append(contextOptions, chromedp.WithNewBrowserContext()),
+	}
+}
+
+// newTab creates a tab in a new browser context with lifecycle events enabled.
+// The tab must be created with its own context, as chromedp binds the tab to the
+// context of its first action.
+func (p *tabPool) newTab() (*pooledTab, error) {
+	ctx, cancel := chromedp.NewContext(p.browser(), p.contextOptions...)
+	tab := &pooledTab{ctx: ctx, cancel: cancel}
+
+	if err := chromedp.Run(ctx, enableLifeCycleEvents()); err != nil {
+		return tab, fmt.Errorf("error creating tab: %w", err)
+	}
+
+	return tab, nil
+}
+
+// tab returns a tab of the pool, which is returned to the pool when it is closed.
+// If no tab can be created, the actions of the tab return the error. The actions of
+// the tab are canceled along with requestCtx.
+func (p *tabPool) tab(requestCtx context.Context, closed <-chan struct{}) *Tab {
+	pooled, err := p.get()
+	ctx, cancel := context.WithCancel(pooled.ctx)
+
+	// The tab lives in the context of the browser, so the request is linked to it.
+	stop := context.AfterFunc(requestCtx, cancel)
+
+	p.busy.Add(1)
+
+	tab := &Tab{
+		ctx: ctx,
+		cancel: func() {
+			stop()
+			cancel()
+		},
+		requestCtx: requestCtx,
+		closed:     closed,
+		pool:       p,
+		pooled:     pooled,
+		err:        err,
+	}
+
+	if err == nil {
+		tab.diagnostics = recordDiagnostics(ctx)
+	}
+
+	return tab
+}
+
+// warm fills the pool with new tabs. Tabs that cannot be created are logged and skipped.
+func (p *tabPool) warm() {
+	if p.options.MaxUses == 0 {
+		return
+	}
+
+	p.mu.Lock()
+	missing := p.options.Size - len(p.tabs)
+	p.mu.Unlock()
+
+	for range missing {
+		tab, err := p.newTab()
+		if err != nil {
+			p.logger.Warn("failed to create warm tab", "err", err)
+			tab.cancel()
+
+			return
+		}
+
+		if !p.add(tab) {
+			tab.cancel()
+
+			return
+		}
+	}
+
+	p.logger.Debug("warmed up tab pool", "size", p.options.Size)
+}
+
+// add adds an idle tab to the pool. It returns false if the pool is full or closed.
+func (p *tabPool) add(tab *pooledTab) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed || len(p.tabs) >= p.options.Size {
+		return false
+	}
+
+	p.tabs = append(p.tabs, tab)
+
+	return true
+}
+
+// get returns an idle tab of the pool or a new one, if the pool is empty. Tabs of a
+// crashed browser are dropped.
+func (p *tabPool) get() (*pooledTab, error) {
+	for {
+		p.mu.Lock()
+		if len(p.tabs) == 0 {
+			p.mu.Unlock()
+
+			return p.newTab()
+		}
+
+		tab := p.tabs[len(p.tabs)-1]
+		p.tabs = p.tabs[:len(p.tabs)-1]
+		p.mu.Unlock()
+
+		if tab.ctx.Err() == nil {
+			return tab, nil
+		}
+
+		tab.cancel()
+	}
+}
+
+// put resets the tab and returns it to the pool. Tabs that reached their maximum
+// number of uses are replaced by a new warm tab.
+func (p *tabPool) put(tab *pooledTab) {
+	tab.uses++
+
+	if tab.uses >= p.options.MaxUses || tab.ctx.Err() != nil {
+		tab.cancel()
+
+		if p.options.MaxUses > 0 && p.browser().Err() == nil {
+			p.warm()
+		}
+
+		return
+	}
+
+	if err := resetTab(tab.ctx); err != nil {
+		p.logger.Warn("failed to reset tab, closing it", "err", err)
+		tab.cancel()
+
+		return
+	}
+
+	if !p.add(tab) {
+		tab.cancel()
+	}
+}
+
+// drain closes all idle tabs, e.g., after the browser crashed.
+func (p *tabPool) drain() {
+	p.mu.Lock()
+	tabs := p.tabs
+	p.tabs = nil
+	p.mu.Unlock()
+
+	for _, tab := range tabs {
+		tab.cancel()
+	}
+}
+
+// close closes all idle tabs and stops returning tabs to the pool.
+func (p *tabPool) close() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	p.drain()
+}
+
+// resetTab removes the cookies, the storage, the headers and the viewport left by
+// the previous use of the tab and navigates it to a blank page.
+func resetTab(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, tabResetTimeout)
+	defer cancel()
+
+	var origin string
+
+	err := chromedp.Run(ctx,
+		chromedp.Evaluate(`window.location.origin`, &origin),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			// Pages like about:blank do not have an origin.
+			if origin == "" || origin == "null" {
+				return nil
+			}
+
+			return storage.ClearDataForOrigin(origin, "all").Do(ctx)
+		}),
+		network.ClearBrowserCookies(),
+		network.SetExtraHTTPHeaders(network.Headers{}),
+		emulation.ClearDeviceMetricsOverride(),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			return browser.SetDownloadBehavior(browser.SetDownloadBehaviorBehaviorDefault).
+				WithBrowserContextID(chromedp.FromContext(ctx).BrowserContextID).
+				Do(ctx)
+		}),
+		chromedp.Navigate("about:blank"),
+	)
+	if err != nil {
+		return fmt.Errorf("error resetting tab: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package chrome
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
+	"golang.org/x/net/context"
+)
+
+// readinessPollInterval is the interval to check the loading state of the page.
+const readinessPollInterval = 100 * time.Millisecond
+
+// javascriptPanelState returns the number of rendered panels and the number of loading
+// bars of the page. The preloader is shown until the Grafana frontend has booted.
+const javascriptPanelState = `({
+	booted: document.readyState === 'complete' && document.querySelector('.preloader') === null,
+	panels: document.querySelectorAll('.react-grid-item, .panel-solo, [data-panelid], [data-viz-panel-key], [data-testid="data-testid panel content"]').length,
+	loading: document.querySelectorAll('[aria-label*="loading bar" i], [data-testid*="loading bar" i], .panel-loading').length,
+})`
+
+// panelState is the loading state of the panels of a page.
+type panelState struct {
+	Booted  bool `json:"booted"`
+	Panels  int  `json:"panels"`
+	Loading int  `json:"loading"`
+
+	// PendingQueries are the data source queries, which are still loading.
+	PendingQueries int `json:"-"`
+}
+
+// ready returns true if all panels are rendered and nothing is loading anymore.
+func (s panelState) ready() bool {
+	return s.Booted && s.Panels > 0 && s.Loading == 0 && s.PendingQueries == 0
+}
+
+func (s panelState) String() string {
+	return fmt.Sprintf("%d panels, %d loading, %d queries pending", s.Panels, s.Loading, s.PendingQueries)
+}
+
+// NavigateAndWaitReady navigates to the given address and waits until the page is ready.
+func (t *Tab) NavigateAndWaitReady(addr string, headers map[string]any, readiness config.Readiness) error {
+	if err := t.navigate(addr, headers); err != nil {
+		return err
+	}
+
+	if err := t.WaitReady(readiness); err != nil {
+		return fmt.Errorf("error waiting for page %s: %w", addr, err)
+	}
+
+	return nil
+}
+
+// WaitReady waits until the page is ready according to the readiness strategy, e.g.,
+// again after scrolling lazy loaded panels into view.
+func (t *Tab) WaitReady(readiness config.Readiness) error {
+	if t.err != nil {
+		return t.wrapError(t.err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.ctx, time.Duration(readiness.Timeout))
+	defer cancel()
+
+	if readiness.Strategy == config.ReadinessNetworkIdle {
+		if err := chromedp.Run(ctx, waitFor("networkIdle")); err != nil {
+			return fmt.Errorf("error waiting for networkIdle: %w", t.wrapError(err))
+		}
+
+		return t.wrapError(sleep(ctx, time.Duration(readiness.SettleDelay)))
+	}
+
+	return t.waitPanelsLoaded(ctx, time.Duration(readiness.SettleDelay))
+}
+
+// waitPanelsLoaded polls the loading state of the panels until the page stayed ready
+// for the settle delay.
+func (t *Tab) waitPanelsLoaded(ctx context.Context, settleDelay time.Duration) error {
+	ticker := time.NewTicker(readinessPollInterval)
+	defer ticker.Stop()
+
+	var (
+		state      panelState
+		readySince time.Time
+	)
+
+	for {
+		state = panelState{}
+
+		// The page might be navigating, so errors only mean that it is not ready yet.
+		if err := chromedp.Run(ctx, chromedp.Evaluate(javascriptPanelState, &state)); err != nil && t.pooled.ctx.Err() != nil {
+			return t.wrapError(err)
+		}
+
+		if t.diagnostics != nil {
+			state.PendingQueries = t.diagnostics.pendingQueries()
+		}
+
+		switch {
+		case !state.ready():
+			readySince = time.Time{}
+		case readySince.IsZero():
+			readySince = time.Now()
+		case time.Since(readySince) >= settleDelay:
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s: %w", ErrPageNotReady, state, t.wrapError(ctx.Err()))
+		case <-ticker.C:
+		}
+	}
+}
+
+// sleep waits for the duration or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
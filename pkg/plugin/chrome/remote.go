@@ -1,25 +1,281 @@
 package chrome
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/chromedp/cdproto/browser"
 	"github.com/chromedp/chromedp"
 	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
+	"github.com/gobwas/ws"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 	"golang.org/x/net/context"
 )
 
-// RemoteInstance is a remotely running browser instance.
+// Interval and timeout of the health checks of remote browsers.
+const (
+	healthCheckInterval = 10 * time.Second
+	healthCheckTimeout  = 5 * time.Second
+)
+
+// Strategies to spread tabs across remote browsers.
+const (
+	RoundRobin = "round-robin"
+	LeastBusy  = "least-busy"
+)
+
+// RemoteInstance is a set of remotely running browser instances. Tabs are spread
+// across the healthy browsers, failed browsers are ejected until their health
+// check succeeds again.
 type RemoteInstance struct {
-	allocCtx       context.Context
-	allocCtxCancel context.CancelFunc
+	logger  log.Logger
+	options RemoteOptions
+	dialer  *ws.Dialer
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	endpoints []*endpoint
+	next      atomic.Uint64
+}
+
+// endpoint is a single remote browser.
+type endpoint struct {
+	url  string
+	tabs *tabPool
+
+	mu          sync.RWMutex
+	wsURL       string
+	allocCtx    context.Context
+	allocCancel context.CancelFunc
+	version     string
+	healthy     bool
+	lastError   string
+}
+
+// NewRemoteBrowserInstance creates a new remote browser instance for the given browser
+// URLs. Each tab connects to its remote browser on its own. The connection to each
+// browser is tested and the version of the browser is logged.
+func NewRemoteBrowserInstance(ctx context.Context, logger log.Logger, options RemoteOptions,
+	tabPool TabPoolOptions,
+) (*RemoteInstance, error) {
+	if len(options.URLs) == 0 {
+		return nil, errors.New("no remote chrome URL given")
+	}
+
+	chromeLogger := logger.With("subsystem", "chromium")
+
+	i := &RemoteInstance{
+		logger:  chromeLogger,
+		options: options,
+		dialer: &ws.Dialer{
+			Header:    ws.HandshakeHeaderHTTP(options.header()),
+			TLSConfig: options.TLSConfig,
+		},
+	}
+	i.ctx, i.cancel = context.WithCancel(ctx)
+
+	// Split the warm tabs across the browsers.
+	tabPool.Size = (tabPool.Size + len(options.URLs) - 1) / len(options.URLs)
+
+	for _, remoteChromeURL := range options.URLs {
+		// Until the debugger URL is discovered, the URL is used as is.
+		e := &endpoint{url: remoteChromeURL, wsURL: remoteChromeURL, healthy: true}
+		e.allocCtx, e.allocCancel = i.newAllocator(remoteChromeURL)
+
+		e.tabs = newTabPool(chromeLogger.With("endpoint", e.name()), tabPool,
+			func() context.Context {
+				e.mu.RLock()
+				defer e.mu.RUnlock()
+
+				return e.allocCtx
+			},
+			chromedp.WithErrorf(func(s string, i ...interface{}) {
+				chromeLogger.Error(fmt.Sprintf(s, i...))
+			}),
+			chromedp.WithLogf(func(s string, i ...interface{}) {
+				chromeLogger.Debug(fmt.Sprintf(s, i...))
+			}),
+		)
+
+		i.endpoints = append(i.endpoints, e)
+
+		remoteBrowserUp.WithLabelValues(e.name()).Set(1)
+
+		if err := i.connect(e); err != nil {
+			i.updateHealth(e, err)
+
+			continue
+		}
+
+		go e.tabs.warm()
+	}
+
+	go i.checkHealth()
+
+	return i, nil
+}
+
+// name returns the URL of the endpoint without credentials and query, e.g., for logs.
+func (e *endpoint) name() string {
+	u, err := url.Parse(e.url)
+	if err != nil {
+		return "invalid URL"
+	}
+
+	return u.Scheme + "://" + u.Host + u.Path
+}
+
+// isHealthy returns true if the endpoint was not ejected.
+func (e *endpoint) isHealthy() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.healthy
 }
 
-// NewRemoteBrowserInstance creates a new remote browser instance.
-func NewRemoteBrowserInstance(ctx context.Context, _ log.Logger, remoteChromeURL string) (*RemoteInstance, error) {
-	allocCtx, allocCtxCancel := chromedp.NewRemoteAllocator(ctx, remoteChromeURL)
+// setHealth updates the health of the endpoint. It returns true if the health changed.
+func (e *endpoint) setHealth(err error) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	healthy := err == nil
+	changed := healthy != e.healthy
+
+	e.healthy = healthy
+	if err != nil {
+		e.lastError = err.Error()
+	}
 
-	return &RemoteInstance{allocCtx, allocCtxCancel}, nil
+	if healthy {
+		remoteBrowserUp.WithLabelValues(e.name()).Set(1)
+	} else {
+		remoteBrowserUp.WithLabelValues(e.name()).Set(0)
+	}
+
+	return changed
+}
+
+// checkHealth checks the health of all endpoints periodically until the instance is closed.
+func (i *RemoteInstance) checkHealth() {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-i.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, e := range i.endpoints {
+			i.updateHealth(e, i.connect(e))
+		}
+	}
+}
+
+// updateHealth sets the health of the endpoint and logs changes.
+func (i *RemoteInstance) updateHealth(e *endpoint, err error) {
+	if i.ctx.Err() != nil || !e.setHealth(err) {
+		return
+	}
+
+	if err != nil {
+		i.logger.Error("remote browser is unhealthy, ejecting it", "endpoint", e.name(), "err", err)
+		e.tabs.drain()
+
+		return
+	}
+
+	i.logger.Info("remote browser is healthy again", "endpoint", e.name())
+
+	go e.tabs.warm()
+}
+
+// connect discovers the debugger URL of the browser and tests the connection to it.
+// When the debugger URL changed, e.g., because the browser was restarted, new tabs
+// connect to the new URL.
+func (i *RemoteInstance) connect(e *endpoint) error {
+	ctx, cancel := context.WithTimeout(i.ctx, healthCheckTimeout)
+	defer cancel()
+
+	wsURL, err := i.options.debuggerURL(ctx, e.url)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+
+	var oldCancel context.CancelFunc
+
+	if wsURL != e.wsURL {
+		oldCancel = e.allocCancel
+		e.wsURL = wsURL
+		e.allocCtx, e.allocCancel = i.newAllocator(wsURL)
+	}
+
+	allocCtx := e.allocCtx
+	e.mu.Unlock()
+
+	// Tabs of the old URL cannot connect anymore.
+	if oldCancel != nil {
+		e.tabs.drain()
+		oldCancel()
+	}
+
+	version, err := probe(allocCtx)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	changed := version != e.version
+	e.version = version
+	e.mu.Unlock()
+
+	if changed {
+		i.logger.Info("connected to remote browser", "endpoint", e.name(), "version", version)
+	}
+
+	return nil
+}
+
+// newAllocator returns the allocator of the browser at wsURL, which is dialed with the
+// headers and TLS settings of the instance.
+func (i *RemoteInstance) newAllocator(wsURL string) (context.Context, context.CancelFunc) {
+	allocCtx, allocCancel := chromedp.NewRemoteAllocator(i.ctx, wsURL, chromedp.NoModifyURL)
+	registerDialer(allocCtx, wsURL, i.dialer)
+
+	return allocCtx, allocCancel
+}
+
+// probe opens a tab on the remote browser and returns its version.
+func probe(allocCtx context.Context) (string, error) {
+	ctx, cancel := chromedp.NewContext(allocCtx)
+	defer cancel()
+
+	ctx, cancelTimeout := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancelTimeout()
+
+	var product string
+
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+
+		_, product, _, _, _, err = browser.GetVersion().Do(ctx) //nolint:dogsled
+
+		return err //nolint:wrapcheck
+	}))
+	if err != nil {
+		return "", fmt.Errorf("health check failed: %w", err)
+	}
+
+	return product, nil
 }
 
 // Name returns the kind of browser instance.
@@ -27,26 +283,112 @@ func (i *RemoteInstance) Name() string {
 	return "remote"
 }
 
-// NewTab starts and returns a new tab on current browser instance.
-func (i *RemoteInstance) NewTab(logger log.Logger, _ config.Config) *Tab {
-	chromeLogger := logger.With("subsystem", "chromium")
-	browserCtx, _ := chromedp.NewContext(i.allocCtx,
-		chromedp.WithErrorf(func(s string, i ...interface{}) {
-			chromeLogger.Error(fmt.Sprintf(s, i...))
-		}),
-		chromedp.WithLogf(func(s string, i ...interface{}) {
-			chromeLogger.Debug(fmt.Sprintf(s, i...))
-		}),
-	)
+// candidates returns the endpoints in the order in which tabs are opened on them.
+// Healthy endpoints are ordered by the balancing strategy, ejected endpoints are
+// only used as a last resort.
+func (i *RemoteInstance) candidates() []*endpoint {
+	start := int(i.next.Add(1)-1) % len(i.endpoints)
+	ordered := append(slices.Clone(i.endpoints[start:]), i.endpoints[:start]...)
+
+	if i.options.Balancing == LeastBusy {
+		slices.SortStableFunc(ordered, func(a, b *endpoint) int {
+			return int(a.tabs.busy.Load() - b.tabs.busy.Load())
+		})
+	}
+
+	slices.SortStableFunc(ordered, func(a, b *endpoint) int {
+		switch {
+		case a.isHealthy() == b.isHealthy():
+			return 0
+		case a.isHealthy():
+			return -1
+		default:
+			return 1
+		}
+	})
+
+	return ordered
+}
+
+// NewTab returns a warm tab of the pool or starts a new tab on one of the remote
+// browsers. If the tab cannot connect to a browser, the browser is ejected and the
+// next one is tried.
+func (i *RemoteInstance) NewTab(ctx context.Context, _ log.Logger, _ config.Config) *Tab {
+	candidates := i.candidates()
+
+	for _, e := range candidates[:len(candidates)-1] {
+		tab := e.tabs.tab(ctx, i.ctx.Done())
+		if tab.err == nil || i.ctx.Err() != nil || ctx.Err() != nil {
+			return tab
+		}
+
+		i.logger.Warn("failed to open tab on remote browser, failing over", "endpoint", e.name(), "err", tab.err)
+		i.updateHealth(e, tab.err)
+		tab.Close(i.logger)
+	}
+
+	// If the last browser fails as well, its error is returned by the actions of the tab.
+	last := candidates[len(candidates)-1]
+
+	tab := last.tabs.tab(ctx, i.ctx.Done())
+	if tab.err != nil {
+		i.updateHealth(last, tab.err)
+	}
+
+	return tab
+}
+
+// Stats returns the state of the remote browsers. Each tab connects to the remote
+// browser on its own, so there is nothing to restart. The instance is healthy as long
+// as one of the browsers is healthy.
+func (i *RemoteInstance) Stats() Stats {
+	stats := Stats{Endpoints: make([]EndpointStats, 0, len(i.endpoints))}
 
-	return &Tab{
-		ctx: browserCtx,
+	for _, e := range i.endpoints {
+		e.mu.RLock()
+		endpointStats := EndpointStats{
+			URL:       e.name(),
+			Version:   e.version,
+			Healthy:   e.healthy,
+			Busy:      e.tabs.busy.Load(),
+			LastError: e.lastError,
+		}
+		e.mu.RUnlock()
+
+		stats.Endpoints = append(stats.Endpoints, endpointStats)
+		stats.Healthy = stats.Healthy || (endpointStats.Healthy && i.ctx.Err() == nil)
+	}
+
+	return stats
+}
+
+// WaitHealthy blocks until one of the remote browsers is healthy.
+func (i *RemoteInstance) WaitHealthy(ctx context.Context) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		if i.ctx.Err() != nil {
+			return ErrBrowserClosed
+		}
+
+		if slices.ContainsFunc(i.endpoints, (*endpoint).isHealthy) {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("error waiting for a healthy remote browser: %w", ctx.Err())
+		}
 	}
 }
 
 // Close releases the resources of browser instance.
 func (i *RemoteInstance) Close(_ log.Logger) {
-	if i.allocCtxCancel != nil {
-		i.allocCtxCancel()
+	for _, e := range i.endpoints {
+		e.tabs.close()
 	}
+
+	i.cancel()
 }
@@ -0,0 +1,171 @@
+package chrome_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chromedp/chromedp"
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/chrome"
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteInstanceFailover(t *testing.T) {
+	logger := log.NewNullLogger()
+
+	// Nothing listens on these ports, so every endpoint fails.
+	instance, err := chrome.NewRemoteBrowserInstance(context.Background(), logger, chrome.RemoteOptions{
+		URLs:      []string{"ws://127.0.0.1:1/devtools/browser/a", "ws://127.0.0.1:2/devtools/browser/b"},
+		Balancing: chrome.RoundRobin,
+	}, chrome.TabPoolOptions{})
+	require.NoError(t, err)
+
+	defer instance.Close(logger)
+
+	// The connection test on startup already ejected the endpoints.
+	assert.False(t, instance.Stats().Healthy)
+
+	tab := instance.NewTab(context.Background(), logger, config.Config{})
+	require.Error(t, tab.Run(chromedp.Evaluate(`1`, nil)))
+	tab.Close(logger)
+
+	stats := instance.Stats()
+	assert.False(t, stats.Healthy)
+	require.Len(t, stats.Endpoints, 2)
+
+	for _, endpoint := range stats.Endpoints {
+		assert.False(t, endpoint.Healthy, endpoint.URL)
+		assert.NotEmpty(t, endpoint.LastError, endpoint.URL)
+		assert.Zero(t, endpoint.Busy, endpoint.URL)
+	}
+}
+
+func TestRemoteInstanceDiscovery(t *testing.T) {
+	logger := log.NewNullLogger()
+
+	requests := make(chan *http.Request, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+
+		if r.URL.Path == "/json/version" {
+			_, _ = w.Write([]byte(`{"webSocketDebuggerUrl": "ws://localhost:9222/devtools/browser/abc"}`))
+
+			return
+		}
+
+		// Not a browser, so the websocket connection fails.
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	instance, err := chrome.NewRemoteBrowserInstance(context.Background(), logger, chrome.RemoteOptions{
+		URLs:      []string{server.URL},
+		Balancing: chrome.RoundRobin,
+		Token:     "secret",
+		Headers:   http.Header{"X-Tenant": []string{"reports"}},
+	}, chrome.TabPoolOptions{})
+	require.NoError(t, err)
+
+	defer instance.Close(logger)
+
+	for _, path := range []string{"/json/version", "/devtools/browser/abc"} {
+		r := <-requests
+
+		assert.Equal(t, path, r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"), path)
+		assert.Equal(t, "reports", r.Header.Get("X-Tenant"), path)
+	}
+
+	assert.False(t, instance.Stats().Healthy)
+}
+
+// echoServer returns a websocket server echoing text messages, which records the
+// headers of the handshakes.
+func echoServer(t *testing.T) (*httptest.Server, chan http.Header) {
+	t.Helper()
+
+	headers := make(chan http.Header, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header
+
+		if r.URL.Path != "/echo" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		conn, _, _, err := ws.UpgradeHTTP(r, w)
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		msg, err := wsutil.ReadClientText(conn)
+		if err != nil {
+			return
+		}
+
+		_ = wsutil.WriteServerText(conn, msg)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, headers
+}
+
+func TestRemoteInstanceDialer(t *testing.T) {
+	logger := log.NewNullLogger()
+
+	remote, remoteHeaders := echoServer(t)
+	local, localHeaders := echoServer(t)
+
+	remoteURL := "ws" + strings.TrimPrefix(remote.URL, "http")
+	localURL := "ws" + strings.TrimPrefix(local.URL, "http")
+
+	instance, err := chrome.NewRemoteBrowserInstance(context.Background(), logger, chrome.RemoteOptions{
+		URLs:    []string{remoteURL + "/devtools/browser/abc"},
+		Token:   "secret",
+		Headers: http.Header{"X-Tenant": []string{"reports"}},
+	}, chrome.TabPoolOptions{})
+	require.NoError(t, err)
+
+	defer instance.Close(logger)
+
+	// The connection test on startup dials the browser.
+	assert.Equal(t, "Bearer secret", (<-remoteHeaders).Get("Authorization"))
+
+	for _, tc := range []struct {
+		name    string
+		url     string
+		headers chan http.Header
+		auth    string
+	}{
+		{"remote browser", remoteURL + "/echo", remoteHeaders, "Bearer secret"},
+		{"other browser", localURL + "/echo", localHeaders, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			conn, _, _, err := ws.Dial(context.Background(), tc.url)
+			require.NoError(t, err)
+
+			defer conn.Close()
+
+			header := <-tc.headers
+			assert.Equal(t, tc.auth, header.Get("Authorization"))
+
+			require.NoError(t, wsutil.WriteClientText(conn, []byte("ping")))
+
+			msg, err := wsutil.ReadServerText(conn)
+			require.NoError(t, err)
+			assert.Equal(t, "ping", string(msg))
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package chrome
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"golang.org/x/net/context"
+)
+
+// RetryOnCrash calls fn and calls it once more, if it failed because the browser
+// crashed. Before retrying, it waits until the browser has been restarted.
+// fn must open its own tab, as tabs do not survive a crash of the browser. Canceled
+// requests are not retried.
+func RetryOnCrash(ctx context.Context, instance Instance, logger log.Logger, fn func() error) error {
+	err := fn()
+	if !errors.Is(err, ErrBrowserCrashed) || ctx.Err() != nil {
+		return err
+	}
+
+	logger.Warn("browser crashed while rendering, retrying after restart", "err", err)
+
+	if err := instance.WaitHealthy(ctx); err != nil {
+		return fmt.Errorf("browser crashed and did not recover: %w", err)
+	}
+
+	return fn()
+}
@@ -0,0 +1,80 @@
+package chrome_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/chrome"
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeInstance is a browser instance whose restart succeeds or fails.
+type fakeInstance struct {
+	waitErr error
+}
+
+func (i fakeInstance) NewTab(_ context.Context, _ log.Logger, _ config.Config) *chrome.Tab {
+	return nil
+}
+func (i fakeInstance) Name() string                        { return "fake" }
+func (i fakeInstance) Stats() chrome.Stats                 { return chrome.Stats{} }
+func (i fakeInstance) WaitHealthy(_ context.Context) error { return i.waitErr }
+func (i fakeInstance) Close(_ log.Logger)                  {}
+
+func TestRetryOnCrash(t *testing.T) {
+	t.Parallel()
+
+	errOther := errors.New("other")
+	crashed := fmt.Errorf("error rendering PDF: %w", chrome.ErrBrowserCrashed)
+
+	for _, tc := range []struct {
+		name     string
+		waitErr  error
+		errs     []error
+		calls    int
+		expected error
+	}{
+		{"success", nil, []error{nil}, 1, nil},
+		{"other error is not retried", nil, []error{errOther}, 1, errOther},
+		{"crash is retried", nil, []error{crashed, nil}, 2, nil},
+		{"crash is retried once", nil, []error{crashed, crashed}, 2, chrome.ErrBrowserCrashed},
+		{"closed while restarting", chrome.ErrBrowserClosed, []error{crashed}, 1, chrome.ErrBrowserClosed},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			calls := 0
+
+			err := chrome.RetryOnCrash(context.Background(), fakeInstance{tc.waitErr}, log.NewNullLogger(), func() error {
+				calls++
+
+				return tc.errs[calls-1]
+			})
+
+			assert.Equal(t, tc.calls, calls)
+			assert.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+
+func TestRetryOnCrashWithCanceledRequest(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+
+	err := chrome.RetryOnCrash(ctx, fakeInstance{}, log.NewNullLogger(), func() error {
+		calls++
+
+		return fmt.Errorf("%w: %w", chrome.ErrBrowserCrashed, context.Canceled)
+	})
+
+	assert.Equal(t, 1, calls)
+	assert.ErrorIs(t, err, context.Canceled)
+}
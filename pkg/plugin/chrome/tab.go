@@ -1,6 +1,7 @@
 package chrome
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -23,37 +24,66 @@ var WithAwaitPromise = func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
 type Tab struct {
 	ctx    context.Context
 	cancel context.CancelFunc
+
+	// requestCtx is the context of the request, which uses the tab
+	requestCtx context.Context
+
+	// closed is closed when the browser instance was closed on purpose
+	closed <-chan struct{}
+
+	// pool takes the browser tab back when the tab is closed
+	pool   *tabPool
+	pooled *pooledTab
+
+	// err is the error of creating the browser tab
+	err error
+
+	// diagnostics records the console messages, exceptions and failed requests
+	diagnostics *diagnosticsRecorder
+}
+
+// wrapError marks errors caused by the exit of the browser with ErrBrowserCrashed
+// or ErrBrowserClosed, so that callers can retry the tab on a restarted browser.
+func (t *Tab) wrapError(err error) error {
+	if err == nil || t.pooled.ctx.Err() == nil {
+		return err
+	}
+
+	select {
+	case <-t.closed:
+		return fmt.Errorf("%w: %w", ErrBrowserClosed, err)
+	default:
+		return fmt.Errorf("%w: %w", ErrBrowserCrashed, err)
+	}
 }
 
-// Close releases the resources of the current browser tab.
+// Close releases the current browser tab. The browser tab is reset and returned to the
+// pool in the background.
 func (t *Tab) Close(logger log.Logger) {
-	if t.ctx != nil {
-		var err error
+	if t.Canceled() {
+		tabsCanceled.Inc()
+		logger.Debug("browser tab canceled by request", "err", t.requestCtx.Err())
+	}
 
-		// Clear browser cookies to ensure no session is left
-		if err = chromedp.Run(t.ctx, network.ClearBrowserCookies()); err != nil {
-			logger.Error("got error from clear browser cookies", "error", err)
-		}
+	// Stops the timeouts and the event listeners of this use of the tab.
+	t.cancel()
+	t.pool.busy.Add(-1)
 
-		if err = chromedp.Cancel(t.ctx); err != nil {
-			logger.Error("got error from cancel tab context", "error", err)
-		}
+	if t.err != nil {
+		go t.pooled.cancel()
 
-		if t.cancel != nil {
-			t.cancel()
-		}
+		return
 	}
+
+	go t.pool.put(t.pooled)
 }
 
-// NavigateAndWaitFor navigates to the given address and waits for the given event to be fired on the page.
-func (t *Tab) NavigateAndWaitFor(addr string, headers map[string]any, eventName string) error {
-	err := t.Run(
-		// block some URLs to avoid unnecessary requests
-		network.SetBlockedURLS([]string{"*/api/frontend-metrics", "*/api/live/ws", "*/api/user/*"}),
-		enableLifeCycleEvents(),
-	)
+// navigate blocks unneeded requests, sets the headers and navigates to the given address.
+func (t *Tab) navigate(addr string, headers map[string]any) error {
+	// block some URLs to avoid unnecessary requests
+	err := t.Run(network.SetBlockedURLS([]string{"*/api/frontend-metrics", "*/api/live/ws", "*/api/user/*"}))
 	if err != nil {
-		return fmt.Errorf("error enable lifecycle events: %w", err)
+		return fmt.Errorf("error blocking URLs: %w", err)
 	}
 
 	if headers != nil {
@@ -65,39 +95,57 @@ func (t *Tab) NavigateAndWaitFor(addr string, headers map[string]any, eventName
 
 	resp, err := chromedp.RunResponse(t.ctx, chromedp.Navigate(addr))
 	if err != nil {
-		return fmt.Errorf("failed navigate to %s: %w", addr, err)
+		return fmt.Errorf("failed navigate to %s: %w", addr, t.wrapError(err))
 	}
 
 	if resp.Status != http.StatusOK {
 		return fmt.Errorf("status code is %d:%s", resp.Status, resp.StatusText)
 	}
 
-	err = t.Run(waitFor(eventName))
-	if err != nil {
-		return fmt.Errorf("error waiting for %s on page %s: %w", eventName, addr, err)
-	}
-
 	return nil
 }
 
 // WithTimeout set the timeout for the actions in the current tab.
 func (t *Tab) WithTimeout(timeout time.Duration) {
-	t.ctx, t.cancel = context.WithTimeout(t.ctx, timeout)
+	var (
+		cancel context.CancelFunc
+		parent = t.cancel
+	)
+
+	t.ctx, cancel = context.WithTimeout(t.ctx, timeout)
+	t.cancel = func() {
+		cancel()
+		parent()
+	}
 }
 
 // Run executes the actions in the current tab.
 func (t *Tab) Run(actions ...chromedp.Action) error {
-	return chromedp.Run(t.ctx, actions...) //nolint:wrapcheck
+	if t.err != nil {
+		return t.wrapError(t.err)
+	}
+
+	return t.wrapError(chromedp.Run(t.ctx, actions...))
 }
 
 // Run executes the actions in the current tab.
 func (t *Tab) RunWithTimeout(timeout time.Duration, actions ...chromedp.Action) error {
+	if t.err != nil {
+		return t.wrapError(t.err)
+	}
+
 	ctx, cancel := context.WithTimeout(t.ctx, timeout)
 	err := chromedp.Run(ctx, actions...)
 
 	cancel()
 
-	return err //nolint:wrapcheck
+	return t.wrapError(err)
+}
+
+// Canceled returns true if the request using the tab was canceled, e.g., because the
+// user closed the connection.
+func (t *Tab) Canceled() bool {
+	return errors.Is(t.requestCtx.Err(), context.Canceled)
 }
 
 // Context returns the current tab's context.
@@ -107,6 +155,10 @@ func (t *Tab) Context() context.Context {
 
 // PrintToPDF returns chroms tasks that print the requested HTML into a PDF and returns the PDF stream handle.
 func (t *Tab) PrintToPDF(options PDFOptions, writer io.Writer) error {
+	if t.err != nil {
+		return fmt.Errorf("error rendering PDF: %w", t.wrapError(t.err))
+	}
+
 	err := chromedp.Run(t.ctx, chromedp.Tasks{
 		chromedp.Navigate("about:blank"),
 		chromedp.ActionFunc(func(ctx context.Context) error {
@@ -142,6 +194,17 @@ func (t *Tab) PrintToPDF(options PDFOptions, writer io.Writer) error {
 				pageParams = pageParams.WithLandscape(true)
 			}
 
+			pageParams = pageParams.
+				WithPaperWidth(options.Page.PaperWidth).
+				WithPaperHeight(options.Page.PaperHeight).
+				WithMarginTop(options.Page.MarginTop).
+				WithMarginRight(options.Page.MarginRight).
+				WithMarginBottom(options.Page.MarginBottom).
+				WithMarginLeft(options.Page.MarginLeft).
+				WithScale(options.Page.Scale).
+				WithPageRanges(options.Page.PageRanges).
+				WithPrintBackground(options.Page.PrintBackground)
+
 			// Finally execute and get PDF buffer
 			_, stream, err := pageParams.Do(ctx)
 			if err != nil {
@@ -159,7 +222,7 @@ func (t *Tab) PrintToPDF(options PDFOptions, writer io.Writer) error {
 		}),
 	})
 	if err != nil {
-		return fmt.Errorf("error rendering PDF: %w", err)
+		return fmt.Errorf("error rendering PDF: %w", t.wrapError(err))
 	}
 
 	return nil
@@ -1,22 +1,49 @@
 package chrome
 
 import (
+	"context"
+	"time"
+
 	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 )
 
-// PDFOptions contains the templated HTML Body, Header and Footer strings.
+// PDFOptions contains the templated HTML Body, Header and Footer strings along with
+// the orientation and the setup of the pages.
 type PDFOptions struct {
 	Header string
 	Body   string
 	Footer string
 
 	Orientation string
+	Page        config.PageSetup
+}
+
+// Stats contains the health and the restarts of a browser instance.
+type Stats struct {
+	Healthy     bool      `json:"healthy"`
+	Restarts    int64     `json:"restarts"`
+	LastRestart time.Time `json:"lastRestart,omitempty"`
+	LastError   string    `json:"lastError,omitempty"`
+
+	// Endpoints contains the state of each remote browser.
+	Endpoints []EndpointStats `json:"endpoints,omitempty"`
+}
+
+// EndpointStats contains the health and the load of a remote browser.
+type EndpointStats struct {
+	URL       string `json:"url"`
+	Version   string `json:"version,omitempty"`
+	Healthy   bool   `json:"healthy"`
+	Busy      int64  `json:"busy"`
+	LastError string `json:"lastError,omitempty"`
 }
 
 // Instance is the interface remote and local chrome must implement.
 type Instance interface {
-	NewTab(logger log.Logger, conf config.Config) *Tab
+	NewTab(ctx context.Context, logger log.Logger, conf config.Config) *Tab
 	Name() string
+	Stats() Stats
+	WaitHealthy(ctx context.Context) error
 	Close(logger log.Logger)
 }
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Duration is a time.Duration, which is configured like 500ms or 1m30s in the
+// provisioned config as well as in env vars.
+type Duration time.Duration
+
+// UnmarshalText parses durations like 500ms.
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", text, err)
+	}
+
+	*d = Duration(duration)
+
+	return nil
+}
+
+// MarshalText formats the duration like 500ms.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// String formats the duration like 500ms.
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Sizes of the supported paper formats in inches.
+var paperSizes = map[string][2]float64{
+	"A3":     {297 / mmPerInch, 420 / mmPerInch},
+	"A4":     {210 / mmPerInch, 297 / mmPerInch},
+	"Letter": {8.5, 11},
+	"Legal":  {8.5, 14},
+}
+
+// Units of lengths in inches.
+const (
+	mmPerInch = 25.4
+	pxPerInch = 96
+)
+
+// lengthRegex matches lengths like 2cm, 0.5in or 0.
+var lengthRegex = regexp.MustCompile(`^(\d+(?:\.\d+)?)(mm|cm|in|px)?$`)
+
+// pageRangesRegex matches page ranges like 1-5, 8, 11-.
+var pageRangesRegex = regexp.MustCompile(`^\s*\d+\s*(-\s*\d*\s*)?(,\s*\d+\s*(-\s*\d*\s*)?)*$`)
+
+// PageSetup is the paper and the layout of the pages of the PDF. Lengths are in inches,
+// as expected by the browser.
+type PageSetup struct {
+	// PaperSize is the name of the paper format or custom for custom sizes.
+	PaperSize   string
+	PaperWidth  float64
+	PaperHeight float64
+
+	MarginTop    float64
+	MarginRight  float64
+	MarginBottom float64
+	MarginLeft   float64
+
+	Scale           float64
+	PageRanges      string
+	PrintBackground bool
+}
+
+// PageSetup returns the page setup of the PDF. The paper size is either one of A3, A4,
+// Letter or Legal or a custom size like 200mmx300mm. The margins are given like the
+// CSS margin property with one to four lengths, e.g., 3cm 2px 1cm.
+func (c *Config) PageSetup() (PageSetup, error) {
+	setup := PageSetup{
+		PaperSize:       c.PaperSize,
+		Scale:           c.PageScale,
+		PageRanges:      strings.ReplaceAll(c.PageRanges, " ", ""),
+		PrintBackground: c.PrintBackground,
+	}
+
+	if size, ok := paperSizes[c.PaperSize]; ok {
+		setup.PaperWidth, setup.PaperHeight = size[0], size[1]
+	} else {
+		width, height, ok := strings.Cut(strings.ToLower(c.PaperSize), "x")
+		if !ok {
+			return PageSetup{}, fmt.Errorf("invalid paper size %q: must be one of A3, A4, Letter, Legal or like 200mmx300mm", c.PaperSize)
+		}
+
+		var err error
+
+		if setup.PaperWidth, err = parseLength(width); err != nil || setup.PaperWidth == 0 {
+			return PageSetup{}, fmt.Errorf("invalid paper size %q: width must be a positive length", c.PaperSize)
+		}
+
+		if setup.PaperHeight, err = parseLength(height); err != nil || setup.PaperHeight == 0 {
+			return PageSetup{}, fmt.Errorf("invalid paper size %q: height must be a positive length", c.PaperSize)
+		}
+
+		setup.PaperSize = "custom"
+	}
+
+	margins := make([]float64, 0, 4)
+
+	for _, margin := range strings.Fields(c.PageMargins) {
+		length, err := parseLength(margin)
+		if err != nil {
+			return PageSetup{}, fmt.Errorf("invalid page margins %q: %w", c.PageMargins, err)
+		}
+
+		margins = append(margins, length)
+	}
+
+	// Missing margins are taken from the opposite side like in CSS.
+	switch len(margins) {
+	case 1:
+		margins = append(margins, margins[0], margins[0], margins[0])
+	case 2:
+		margins = append(margins, margins[0], margins[1])
+	case 3:
+		margins = append(margins, margins[1])
+	case 4:
+	default:
+		return PageSetup{}, fmt.Errorf("invalid page margins %q: must be one to four lengths", c.PageMargins)
+	}
+
+	setup.MarginTop, setup.MarginRight, setup.MarginBottom, setup.MarginLeft = margins[0], margins[1], margins[2], margins[3]
+
+	if setup.MarginLeft+setup.MarginRight >= setup.PaperWidth || setup.MarginTop+setup.MarginBottom >= setup.PaperHeight {
+		return PageSetup{}, fmt.Errorf("invalid page margins %q: must be smaller than the paper", c.PageMargins)
+	}
+
+	// The browser only supports scales in this range.
+	if c.PageScale < 0.1 || c.PageScale > 2 {
+		return PageSetup{}, fmt.Errorf("invalid page scale %v: must be between 0.1 and 2", c.PageScale)
+	}
+
+	if c.PageRanges != "" && !pageRangesRegex.MatchString(c.PageRanges) {
+		return PageSetup{}, fmt.Errorf("invalid page ranges %q: must be like 1-5, 8, 11-", c.PageRanges)
+	}
+
+	return setup, nil
+}
+
+// parseLength returns the length in inches. Lengths without unit must be 0.
+func parseLength(s string) (float64, error) {
+	match := lengthRegex.FindStringSubmatch(s)
+	if match == nil {
+		return 0, fmt.Errorf("invalid length %q: must be like 2cm, 20mm, 1in or 10px", s)
+	}
+
+	value, _ := strconv.ParseFloat(match[1], 64)
+
+	switch match[2] {
+	case "mm":
+		return value / mmPerInch, nil
+	case "cm":
+		return value * 10 / mmPerInch, nil
+	case "in":
+		return value, nil
+	case "px":
+		return value / pxPerInch, nil
+	default:
+		if value != 0 {
+			return 0, fmt.Errorf("invalid length %q: unit is missing", s)
+		}
+
+		return 0, nil
+	}
+}
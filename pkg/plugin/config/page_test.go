@@ -0,0 +1,85 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageSetup(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		conf     config.Config
+		expected config.PageSetup
+	}{
+		{
+			"defaults",
+			config.DefaultConfig,
+			config.PageSetup{
+				PaperSize: "Letter", PaperWidth: 8.5, PaperHeight: 11,
+				MarginTop: 3 / 2.54, MarginRight: 2.0 / 96, MarginBottom: 1 / 2.54, MarginLeft: 2.0 / 96,
+				Scale: 1,
+			},
+		},
+		{
+			"custom size with single margin",
+			config.Config{PaperSize: "200mmx10in", PageMargins: "1in", PageScale: 0.5, PageRanges: "1-5, 8", PrintBackground: true},
+			config.PageSetup{
+				PaperSize: "custom", PaperWidth: 200 / 25.4, PaperHeight: 10,
+				MarginTop: 1, MarginRight: 1, MarginBottom: 1, MarginLeft: 1,
+				Scale: 0.5, PageRanges: "1-5,8", PrintBackground: true,
+			},
+		},
+		{
+			"A4 with two margins",
+			config.Config{PaperSize: "A4", PageMargins: "0 10mm", PageScale: 1},
+			config.PageSetup{
+				PaperSize: "A4", PaperWidth: 210 / 25.4, PaperHeight: 297 / 25.4,
+				MarginRight: 10 / 25.4, MarginLeft: 10 / 25.4,
+				Scale: 1,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			setup, err := tc.conf.PageSetup()
+			require.NoError(t, err)
+			assert.InDelta(t, tc.expected.PaperWidth, setup.PaperWidth, 1e-9)
+			assert.InDelta(t, tc.expected.PaperHeight, setup.PaperHeight, 1e-9)
+			assert.InDelta(t, tc.expected.MarginTop, setup.MarginTop, 1e-9)
+			assert.InDelta(t, tc.expected.MarginRight, setup.MarginRight, 1e-9)
+			assert.InDelta(t, tc.expected.MarginBottom, setup.MarginBottom, 1e-9)
+			assert.InDelta(t, tc.expected.MarginLeft, setup.MarginLeft, 1e-9)
+			assert.Equal(t, tc.expected.PaperSize, setup.PaperSize)
+			assert.Equal(t, tc.expected.Scale, setup.Scale) //nolint:testifylint
+			assert.Equal(t, tc.expected.PageRanges, setup.PageRanges)
+			assert.Equal(t, tc.expected.PrintBackground, setup.PrintBackground)
+		})
+	}
+}
+
+func TestPageSetupWithInvalidOptions(t *testing.T) {
+	t.Parallel()
+
+	for name, conf := range map[string]config.Config{
+		"unknown paper size":  {PaperSize: "B5", PageMargins: "0", PageScale: 1},
+		"zero paper width":    {PaperSize: "0x10in", PageMargins: "0", PageScale: 1},
+		"margin without unit": {PaperSize: "A4", PageMargins: "10", PageScale: 1},
+		"too many margins":    {PaperSize: "A4", PageMargins: "1cm 1cm 1cm 1cm 1cm", PageScale: 1},
+		"margins too large":   {PaperSize: "A4", PageMargins: "0 5in", PageScale: 1},
+		"scale too large":     {PaperSize: "A4", PageMargins: "0", PageScale: 3},
+		"invalid page ranges": {PaperSize: "A4", PageMargins: "0", PageScale: 1, PageRanges: "first"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := conf.PageSetup()
+			require.Error(t, err)
+		})
+	}
+}
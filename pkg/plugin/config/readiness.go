@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Strategies to detect that a dashboard finished loading.
+const (
+	// ReadinessGrafana waits until all panels are rendered, no panel is loading and
+	// no query is pending.
+	ReadinessGrafana = "grafana"
+	// ReadinessNetworkIdle waits for the networkIdle lifecycle event of the browser.
+	ReadinessNetworkIdle = "networkIdle"
+)
+
+// Readiness configures how to detect that a dashboard finished loading. The page must
+// stay ready for the settle delay, e.g., for panels that render after their data
+// arrived.
+type Readiness struct {
+	Strategy    string   `json:"strategy"`
+	SettleDelay Duration `json:"settleDelay"`
+	Timeout     Duration `json:"timeout"`
+}
+
+// String implements the stringer interface of Readiness.
+func (r Readiness) String() string {
+	return fmt.Sprintf("%s (settle delay %s, timeout %s)", r.Strategy, r.SettleDelay, r.Timeout)
+}
+
+// Readiness returns the readiness options of the dashboard. Options that are not set
+// in the override of the dashboard are taken from the global config.
+func (c *Config) Readiness(dashboardUID string) Readiness {
+	readiness := Readiness{
+		Strategy:    c.ReadinessStrategy,
+		SettleDelay: c.ReadinessSettleDelay,
+		Timeout:     c.ReadinessTimeout,
+	}
+
+	override, ok := c.ReadinessOverrides[dashboardUID]
+	if !ok {
+		return readiness
+	}
+
+	if override.Strategy != "" {
+		readiness.Strategy = override.Strategy
+	}
+
+	if override.SettleDelay != 0 {
+		readiness.SettleDelay = override.SettleDelay
+	}
+
+	if override.Timeout != 0 {
+		readiness.Timeout = override.Timeout
+	}
+
+	return readiness
+}
+
+func (r Readiness) validate() error {
+	if !slices.Contains([]string{ReadinessGrafana, ReadinessNetworkIdle}, r.Strategy) {
+		return fmt.Errorf("invalid readiness strategy %q: must be one of grafana or networkIdle", r.Strategy)
+	}
+
+	if r.SettleDelay < 0 || r.Timeout <= 0 {
+		return fmt.Errorf("invalid readiness: settle delay %s must not be negative and timeout %s must be positive",
+			r.SettleDelay, r.Timeout)
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// RetryPolicy configures how failed requests, e.g., to the grafana-image-renderer, are
+// retried. The backoff doubles with every attempt up to the maximum backoff and is
+// varied randomly by the jitter, so that retries of parallel requests spread out.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first one.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Jitter is the fraction between 0 and 1, by which the backoff is varied.
+	Jitter float64
+	// StatusCodes are the HTTP status codes, which are retried.
+	StatusCodes []int
+}
+
+// RetryPolicy returns the retry policy of the config.
+func (c *Config) RetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    c.RetryMaxAttempts,
+		InitialBackoff: time.Duration(c.RetryInitialBackoff),
+		MaxBackoff:     time.Duration(c.RetryMaxBackoff),
+		Jitter:         c.RetryJitter,
+		StatusCodes:    c.RetryStatusCodes,
+	}
+}
+
+// Retryable returns true if requests that returned the status code are retried.
+func (p RetryPolicy) Retryable(statusCode int) bool {
+	return slices.Contains(p.StatusCodes, statusCode)
+}
+
+// Backoff returns the delay before the next attempt after the given failed attempt,
+// which starts at 1.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	backoff := p.InitialBackoff
+
+	for range attempt - 1 {
+		if backoff >= p.MaxBackoff {
+			break
+		}
+
+		backoff *= 2
+	}
+
+	backoff = min(backoff, p.MaxBackoff)
+
+	if p.Jitter > 0 {
+		backoff = time.Duration(float64(backoff) * (1 + p.Jitter*(2*rand.Float64()-1))) //nolint:gosec
+	}
+
+	return backoff
+}
+
+func (p RetryPolicy) validate() error {
+	if p.MaxAttempts < 1 {
+		return fmt.Errorf("invalid retry max attempts %d: must be at least 1", p.MaxAttempts)
+	}
+
+	if p.InitialBackoff < 0 || p.MaxBackoff < p.InitialBackoff {
+		return fmt.Errorf("invalid retry backoff: initial backoff %s must not be negative or greater than max backoff %s",
+			p.InitialBackoff, p.MaxBackoff)
+	}
+
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("invalid retry jitter %v: must be between 0 and 1", p.Jitter)
+	}
+
+	for _, statusCode := range p.StatusCodes {
+		if http.StatusText(statusCode) == "" || statusCode < http.StatusBadRequest {
+			return fmt.Errorf("invalid retry status code %d: must be an HTTP error status", statusCode)
+		}
+	}
+
+	return nil
+}
+
+// validateTimeouts returns an error if a timeout of a stage of the report is invalid.
+func (c *Config) validateTimeouts() error {
+	for name, timeout := range map[string]Duration{
+		"tab":    c.TabTimeout,
+		"render": c.RenderTimeout,
+		"PDF":    c.PDFTimeout,
+	} {
+		if timeout <= 0 {
+			return fmt.Errorf("invalid %s timeout %s: must be positive", name, timeout)
+		}
+	}
+
+	if c.ReportTimeout < 0 {
+		return fmt.Errorf("invalid report timeout %s: must not be negative", c.ReportTimeout)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	t.Parallel()
+
+	policy := config.RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+	}
+
+	for attempt, expected := range map[int]time.Duration{
+		1: time.Second,
+		2: 2 * time.Second,
+		3: 4 * time.Second,
+		4: 5 * time.Second,
+		9: 5 * time.Second,
+	} {
+		assert.Equal(t, expected, policy.Backoff(attempt), "attempt %d", attempt)
+	}
+}
+
+func TestRetryPolicyBackoffWithJitter(t *testing.T) {
+	t.Parallel()
+
+	policy := config.RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 10 * time.Second,
+		MaxBackoff:     time.Minute,
+		Jitter:         0.2,
+	}
+
+	for range 100 {
+		backoff := policy.Backoff(2)
+
+		assert.GreaterOrEqual(t, backoff, 16*time.Second)
+		assert.LessOrEqual(t, backoff, 24*time.Second)
+	}
+}
+
+func TestRetryPolicyRetryable(t *testing.T) {
+	t.Parallel()
+
+	policy := config.DefaultConfig.RetryPolicy()
+
+	assert.True(t, policy.Retryable(503))
+	assert.True(t, policy.Retryable(429))
+	assert.False(t, policy.Retryable(404))
+}
@@ -1,10 +1,20 @@
 package config
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
@@ -12,20 +22,65 @@ import (
 	"golang.org/x/net/context"
 )
 
-const SaToken = "saToken"
+// Keys of the secrets in the secure JSON data.
+const (
+	SaToken                   = "saToken"
+	RemoteChromeToken         = "remoteChromeToken"
+	RemoteChromeHeaders       = "remoteChromeHeaders"
+	RemoteChromeTLSCACert     = "remoteChromeTlsCACert"
+	RemoteChromeTLSClientCert = "remoteChromeTlsClientCert"
+	RemoteChromeTLSClientKey  = "remoteChromeTlsClientKey"
+)
+
+// timeShiftRegex matches time shifts like -7d of the comparison period.
+var timeShiftRegex = regexp.MustCompile(`^-?(\d+)(s|m|h|d|w|M|y)$`)
+
+// windowSizeRegex matches window sizes like 1920x1080.
+var windowSizeRegex = regexp.MustCompile(`^(\d{1,5})x(\d{1,5})$`)
+
+// languageRegex matches language tags like en or en-US.
+var languageRegex = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
 
 // DefaultConfig Always start with a default config so that when the plugin is not provisioned
 // with a config, we will still have "non-null" config to work with.
 var DefaultConfig = Config{
-	Theme:              "light",
-	Orientation:        "portrait",
-	Layout:             "simple",
-	DashboardMode:      "default",
-	TimeZone:           "",
-	EncodedLogo:        "",
-	MaxBrowserWorkers:  2,
-	MaxRenderWorkers:   2,
-	RequiredPermission: "Viewer",
+	Theme:                 "light",
+	Orientation:           "portrait",
+	Layout:                "simple",
+	DashboardMode:         "default",
+	TimeZone:              "",
+	EncodedLogo:           "",
+	PanelErrorPolicy:      "off",
+	StatsPercentiles:      []float64{95},
+	CompareLayout:         "side-by-side",
+	TableRepeatHeader:     true,
+	TableWrap:             true,
+	MaxBrowserWorkers:     2,
+	MaxRenderWorkers:      2,
+	WorkerQueueSize:       500,
+	MaxQueuedJobsPerUser:  250,
+	TabMaxUses:            20,
+	RemoteChromeBalancing: "round-robin",
+	ChromeHeadless:        true,
+	PanelRenderer:         "image-renderer",
+	PanelRendererFallback: true,
+	PanelScaleFactor:      1,
+	PaperSize:             "Letter",
+	PageMargins:           "3cm 2px 1cm",
+	PageScale:             1,
+	ReadinessStrategy:     "grafana",
+	ReadinessSettleDelay:  Duration(500 * time.Millisecond),
+	ReadinessTimeout:      Duration(30 * time.Second),
+	TabTimeout:            Duration(time.Minute),
+	RenderTimeout:         Duration(time.Minute),
+	PDFTimeout:            Duration(time.Minute),
+	ReportTimeout:         Duration(10 * time.Minute),
+	RetryMaxAttempts:      3,
+	RetryInitialBackoff:   Duration(10 * time.Second),
+	RetryMaxBackoff:       Duration(time.Minute),
+	RetryJitter:           0.2,
+	RetryStatusCodes:      []int{429, 500, 502, 503, 504},
+	RequiredPermission:    "Viewer",
 	HTTPClientOptions: httpclient.Options{
 		Timeouts: &httpclient.DefaultTimeoutOptions,
 		TLS: &httpclient.TLSOptions{
@@ -36,29 +91,89 @@ var DefaultConfig = Config{
 
 // Config contains plugin settings.
 type Config struct {
-	AppURL             string `env:"GF_REPORTER_PLUGIN_APP_URL, overwrite"               json:"appUrl"`
-	SkipTLSCheck       bool   `env:"GF_REPORTER_PLUGIN_SKIP_TLS_CHECK, overwrite"        json:"skipTlsCheck"`
-	Theme              string `env:"GF_REPORTER_PLUGIN_REPORT_THEME, overwrite"          json:"theme"`
-	Orientation        string `env:"GF_REPORTER_PLUGIN_REPORT_ORIENTATION, overwrite"    json:"orientation"`
-	Layout             string `env:"GF_REPORTER_PLUGIN_REPORT_LAYOUT, overwrite"         json:"layout"`
-	DashboardMode      string `env:"GF_REPORTER_PLUGIN_REPORT_DASHBOARD_MODE, overwrite" json:"dashboardMode"`
-	TimeZone           string `env:"GF_REPORTER_PLUGIN_REPORT_TIMEZONE, overwrite"       json:"timeZone"`
-	EncodedLogo        string `env:"GF_REPORTER_PLUGIN_REPORT_LOGO, overwrite"           json:"logo"`
-	MaxBrowserWorkers  int    `env:"GF_REPORTER_PLUGIN_MAX_BROWSER_WORKERS, overwrite"   json:"maxBrowserWorkers"`
-	MaxRenderWorkers   int    `env:"GF_REPORTER_PLUGIN_MAX_RENDER_WORKERS, overwrite"    json:"maxRenderWorkers"`
-	RemoteChromeURL    string `env:"GF_REPORTER_PLUGIN_REMOTE_CHROME_URL, overwrite"     json:"remoteChromeUrl"`
-	HeaderTemplate     string `env:"GF_REPORTER_PLUGIN_HEADER_TEMPLATE, overwrite"       json:"headerTemplate"`
-	ReportTemplate     string `env:"GF_REPORTER_PLUGIN_REPORT_TEMPLATE, overwrite"       json:"reportTemplate"`
-	FooterTemplate     string `env:"GF_REPORTER_PLUGIN_FOOTER_TEMPLATE, overwrite"       json:"footerTemplate"`
-	RequiredPermission string `env:"GF_REPORTER_PLUGIN_REQUIRED_PERMISSION, overwrite"   json:"requiredPermission"`
+	AppURL                 string   `env:"GF_REPORTER_PLUGIN_APP_URL, overwrite"                   json:"appUrl"`
+	SkipTLSCheck           bool     `env:"GF_REPORTER_PLUGIN_SKIP_TLS_CHECK, overwrite"            json:"skipTlsCheck"`
+	Theme                  string   `env:"GF_REPORTER_PLUGIN_REPORT_THEME, overwrite"              json:"theme"`
+	Orientation            string   `env:"GF_REPORTER_PLUGIN_REPORT_ORIENTATION, overwrite"        json:"orientation"`
+	Layout                 string   `env:"GF_REPORTER_PLUGIN_REPORT_LAYOUT, overwrite"             json:"layout"`
+	DashboardMode          string   `env:"GF_REPORTER_PLUGIN_REPORT_DASHBOARD_MODE, overwrite"     json:"dashboardMode"`
+	TimeZone               string   `env:"GF_REPORTER_PLUGIN_REPORT_TIMEZONE, overwrite"           json:"timeZone"`
+	EncodedLogo            string   `env:"GF_REPORTER_PLUGIN_REPORT_LOGO, overwrite"               json:"logo"`
+	NativeStatPanels       bool     `env:"GF_REPORTER_PLUGIN_NATIVE_STAT_PANELS, overwrite"        json:"nativeStatPanels"`
+	VectorCharts           bool     `env:"GF_REPORTER_PLUGIN_VECTOR_CHARTS, overwrite"             json:"vectorCharts"`
+	RowsOnNewPage          bool     `env:"GF_REPORTER_PLUGIN_ROWS_ON_NEW_PAGE, overwrite"          json:"rowsOnNewPage"`
+	PanelErrorPolicy       string   `env:"GF_REPORTER_PLUGIN_PANEL_ERROR_POLICY, overwrite"        json:"panelErrorPolicy"`
+	PanelStats             bool     `env:"GF_REPORTER_PLUGIN_PANEL_STATS, overwrite"               json:"panelStats"`
+	CompareTo              string   `env:"GF_REPORTER_PLUGIN_COMPARE_TO, overwrite"                json:"compareTo"`
+	CompareLayout          string   `env:"GF_REPORTER_PLUGIN_COMPARE_LAYOUT, overwrite"            json:"compareLayout"`
+	TableMaxRows           int      `env:"GF_REPORTER_PLUGIN_TABLE_MAX_ROWS, overwrite"            json:"tableMaxRows"`
+	TableRepeatHeader      bool     `env:"GF_REPORTER_PLUGIN_TABLE_REPEAT_HEADER, overwrite"       json:"tableRepeatHeader"`
+	TableZebra             bool     `env:"GF_REPORTER_PLUGIN_TABLE_ZEBRA, overwrite"               json:"tableZebra"`
+	TableWrap              bool     `env:"GF_REPORTER_PLUGIN_TABLE_WRAP, overwrite"                json:"tableWrap"`
+	TableLandscapeColumns  int      `env:"GF_REPORTER_PLUGIN_TABLE_LANDSCAPE_COLUMNS, overwrite"   json:"tableLandscapeColumns"`
+	MaxBrowserWorkers      int      `env:"GF_REPORTER_PLUGIN_MAX_BROWSER_WORKERS, overwrite"       json:"maxBrowserWorkers"`
+	MaxRenderWorkers       int      `env:"GF_REPORTER_PLUGIN_MAX_RENDER_WORKERS, overwrite"        json:"maxRenderWorkers"`
+	WorkerQueueSize        int      `env:"GF_REPORTER_PLUGIN_WORKER_QUEUE_SIZE, overwrite"         json:"workerQueueSize"`
+	MaxQueuedJobsPerUser   int      `env:"GF_REPORTER_PLUGIN_MAX_QUEUED_JOBS_PER_USER, overwrite"  json:"maxQueuedJobsPerUser"`
+	MaxJobsPerUser         int      `env:"GF_REPORTER_PLUGIN_MAX_JOBS_PER_USER, overwrite"         json:"maxJobsPerUser"`
+	MaxJobsPerOrg          int      `env:"GF_REPORTER_PLUGIN_MAX_JOBS_PER_ORG, overwrite"          json:"maxJobsPerOrg"`
+	TabMaxUses             int      `env:"GF_REPORTER_PLUGIN_TAB_MAX_USES, overwrite"              json:"tabMaxUses"`
+	RemoteChromeURL        string   `env:"GF_REPORTER_PLUGIN_REMOTE_CHROME_URL, overwrite"         json:"remoteChromeUrl"`
+	RemoteChromeBalancing  string   `env:"GF_REPORTER_PLUGIN_REMOTE_CHROME_BALANCING, overwrite"   json:"remoteChromeBalancing"`
+	RemoteChromeTokenParam string   `env:"GF_REPORTER_PLUGIN_REMOTE_CHROME_TOKEN_PARAM, overwrite" json:"remoteChromeTokenParam"`
+	ChromeExecPath         string   `env:"GF_REPORTER_PLUGIN_CHROME_EXEC_PATH, overwrite"          json:"chromeExecPath"`
+	ChromeProxyServer      string   `env:"GF_REPORTER_PLUGIN_CHROME_PROXY_SERVER, overwrite"       json:"chromeProxyServer"`
+	ChromeUserDataDir      string   `env:"GF_REPORTER_PLUGIN_CHROME_USER_DATA_DIR, overwrite"      json:"chromeUserDataDir"`
+	ChromeWindowSize       string   `env:"GF_REPORTER_PLUGIN_CHROME_WINDOW_SIZE, overwrite"        json:"chromeWindowSize"`
+	ChromeLanguage         string   `env:"GF_REPORTER_PLUGIN_CHROME_LANGUAGE, overwrite"           json:"chromeLanguage"`
+	ChromeHeadless         bool     `env:"GF_REPORTER_PLUGIN_CHROME_HEADLESS, overwrite"           json:"chromeHeadless"`
+	PanelRenderer          string   `env:"GF_REPORTER_PLUGIN_PANEL_RENDERER, overwrite"            json:"panelRenderer"`
+	PanelRendererFallback  bool     `env:"GF_REPORTER_PLUGIN_PANEL_RENDERER_FALLBACK, overwrite"   json:"panelRendererFallback"`
+	PanelScaleFactor       float64  `env:"GF_REPORTER_PLUGIN_PANEL_SCALE_FACTOR, overwrite"        json:"panelScaleFactor"`
+	PaperSize              string   `env:"GF_REPORTER_PLUGIN_PAPER_SIZE, overwrite"                json:"paperSize"`
+	PageMargins            string   `env:"GF_REPORTER_PLUGIN_PAGE_MARGINS, overwrite"              json:"pageMargins"`
+	PageScale              float64  `env:"GF_REPORTER_PLUGIN_PAGE_SCALE, overwrite"                json:"pageScale"`
+	PageRanges             string   `env:"GF_REPORTER_PLUGIN_PAGE_RANGES, overwrite"               json:"pageRanges"`
+	PrintBackground        bool     `env:"GF_REPORTER_PLUGIN_PRINT_BACKGROUND, overwrite"          json:"printBackground"`
+	ReadinessStrategy      string   `env:"GF_REPORTER_PLUGIN_READINESS_STRATEGY, overwrite"        json:"readinessStrategy"`
+	ReadinessSettleDelay   Duration `env:"GF_REPORTER_PLUGIN_READINESS_SETTLE_DELAY, overwrite"    json:"readinessSettleDelay"`
+	ReadinessTimeout       Duration `env:"GF_REPORTER_PLUGIN_READINESS_TIMEOUT, overwrite"         json:"readinessTimeout"`
+	TabTimeout             Duration `env:"GF_REPORTER_PLUGIN_TAB_TIMEOUT, overwrite"               json:"tabTimeout"`
+	RenderTimeout          Duration `env:"GF_REPORTER_PLUGIN_RENDER_TIMEOUT, overwrite"            json:"renderTimeout"`
+	PDFTimeout             Duration `env:"GF_REPORTER_PLUGIN_PDF_TIMEOUT, overwrite"               json:"pdfTimeout"`
+	ReportTimeout          Duration `env:"GF_REPORTER_PLUGIN_REPORT_TIMEOUT, overwrite"            json:"reportTimeout"`
+	RetryMaxAttempts       int      `env:"GF_REPORTER_PLUGIN_RETRY_MAX_ATTEMPTS, overwrite"        json:"retryMaxAttempts"`
+	RetryInitialBackoff    Duration `env:"GF_REPORTER_PLUGIN_RETRY_INITIAL_BACKOFF, overwrite"     json:"retryInitialBackoff"`
+	RetryMaxBackoff        Duration `env:"GF_REPORTER_PLUGIN_RETRY_MAX_BACKOFF, overwrite"         json:"retryMaxBackoff"`
+	RetryJitter            float64  `env:"GF_REPORTER_PLUGIN_RETRY_JITTER, overwrite"              json:"retryJitter"`
+	// ReadinessOverrides overrides the readiness options for dashboards by their UID.
+	ReadinessOverrides map[string]Readiness `json:"readinessOverrides"`
+	HeaderTemplate     string               `env:"GF_REPORTER_PLUGIN_HEADER_TEMPLATE, overwrite"     json:"headerTemplate"`
+	ReportTemplate     string               `env:"GF_REPORTER_PLUGIN_REPORT_TEMPLATE, overwrite"     json:"reportTemplate"`
+	FooterTemplate     string               `env:"GF_REPORTER_PLUGIN_FOOTER_TEMPLATE, overwrite"     json:"footerTemplate"`
+	RequiredPermission string               `env:"GF_REPORTER_PLUGIN_REQUIRED_PERMISSION, overwrite" json:"requiredPermission"`
 	IncludePanelIDs    []int
 	ExcludePanelIDs    []int
+	IncludePanelTitles []string  `env:"GF_REPORTER_PLUGIN_INCLUDE_PANEL_TITLES, overwrite, delimiter=;" json:"includePanelTitles"`
+	ExcludePanelTitles []string  `env:"GF_REPORTER_PLUGIN_EXCLUDE_PANEL_TITLES, overwrite, delimiter=;" json:"excludePanelTitles"`
+	IncludePanelTypes  []string  `env:"GF_REPORTER_PLUGIN_INCLUDE_PANEL_TYPES, overwrite"               json:"includePanelTypes"`
+	ExcludePanelTypes  []string  `env:"GF_REPORTER_PLUGIN_EXCLUDE_PANEL_TYPES, overwrite"               json:"excludePanelTypes"`
+	IncludeRowTitles   []string  `env:"GF_REPORTER_PLUGIN_INCLUDE_ROW_TITLES, overwrite, delimiter=;"   json:"includeRowTitles"`
+	ExcludeRowTitles   []string  `env:"GF_REPORTER_PLUGIN_EXCLUDE_ROW_TITLES, overwrite, delimiter=;"   json:"excludeRowTitles"`
+	StatsPercentiles   []float64 `env:"GF_REPORTER_PLUGIN_STATS_PERCENTILES, overwrite"                 json:"statsPercentiles"`
+	ChromeFlags        []string  `env:"GF_REPORTER_PLUGIN_CHROME_FLAGS, overwrite, delimiter=;"         json:"chromeFlags"`
+	RetryStatusCodes   []int     `env:"GF_REPORTER_PLUGIN_RETRY_STATUS_CODES, overwrite"                json:"retryStatusCodes"`
 
 	// HTTP Client
 	HTTPClientOptions httpclient.Options
 
 	// Secrets
-	Token string
+	Token                     string
+	RemoteChromeToken         string
+	RemoteChromeHeaders       string
+	RemoteChromeTLSCACert     string
+	RemoteChromeTLSClientCert string
+	RemoteChromeTLSClientKey  string
 }
 
 // String implements the stringer interface of Config.
@@ -90,6 +205,9 @@ func (c *Config) String() string {
 		excludedPanelIDs = strings.Join(panelIDs, ",")
 	}
 
+	includedPanels := joinFilters("all", c.IncludePanelTitles, c.IncludePanelTypes, c.IncludeRowTitles)
+	excludedPanels := joinFilters("none", c.ExcludePanelTitles, c.ExcludePanelTypes, c.ExcludeRowTitles)
+
 	appURL := "unset"
 	if c.AppURL != "" {
 		appURL = c.AppURL
@@ -98,23 +216,359 @@ func (c *Config) String() string {
 	return fmt.Sprintf(
 		"Theme: %s; Orientation: %s; Layout: %s; Dashboard Mode: %s; Time Zone: %s; Encoded Logo: %s; "+
 			"Max Renderer Workers: %d; Max Browser Workers: %d; Remote Chrome Addr: %s; App URL: %s; "+
-			"TLS Skip verifiy: %v; Included Panel IDs: %s; Excluded Panel IDs: %s; Required Permission: %s",
+			"TLS Skip verifiy: %v; Included Panel IDs: %s; Excluded Panel IDs: %s; Required Permission: %s; "+
+			"Native Stat Panels: %v; Vector Charts: %v; Rows On New Page: %v; Panel Error Policy: %s; Included Panels (titles|types|rows): %s; "+
+			"Excluded Panels (titles|types|rows): %s; Panel Stats: %v; Stats Percentiles: %v; Compare To: %s; Compare Layout: %s; "+
+			"Table Max Rows: %d; Table Repeat Header: %v; Table Zebra: %v; Table Wrap: %v; Table Landscape Columns: %d; "+
+			"Tab Max Uses: %d; Remote Chrome Balancing: %s; Remote Chrome Token Param: %s; Chrome Exec Path: %s; "+
+			"Chrome Flags: %v; Chrome Proxy Server: %s; Chrome User Data Dir: %s; Chrome Window Size: %s; "+
+			"Chrome Language: %s; Chrome Headless: %v; Panel Renderer: %s; Panel Renderer Fallback: %v; "+
+			"Panel Scale Factor: %v; Paper Size: %s; Page Margins: %s; Page Scale: %v; Page Ranges: %s; "+
+			"Print Background: %v; Readiness: %s; Readiness Overrides: %d; Tab Timeout: %s; Render Timeout: %s; "+
+			"PDF Timeout: %s; Report Timeout: %s; Retry Max Attempts: %d; Retry Backoff: %s-%s; Retry Jitter: %v; "+
+			"Retry Status Codes: %v; Worker Queue Size: %d; Max Queued Jobs Per User: %d; Max Jobs Per User: %d; "+
+			"Max Jobs Per Org: %d",
 		c.Theme, c.Orientation, c.Layout,
 		c.DashboardMode, c.TimeZone, encodedLogo, c.MaxRenderWorkers, c.MaxBrowserWorkers,
 		c.RemoteChromeURL, appURL,
 		c.SkipTLSCheck, includedPanelIDs, excludedPanelIDs, c.RequiredPermission,
+		c.NativeStatPanels, c.VectorCharts, c.RowsOnNewPage, c.PanelErrorPolicy, includedPanels, excludedPanels,
+		c.PanelStats, c.StatsPercentiles, c.CompareTo, c.CompareLayout,
+		c.TableMaxRows, c.TableRepeatHeader, c.TableZebra, c.TableWrap, c.TableLandscapeColumns,
+		c.TabMaxUses, c.RemoteChromeBalancing, c.RemoteChromeTokenParam, c.ChromeExecPath,
+		c.ChromeFlags, c.ChromeProxyServer, c.ChromeUserDataDir, c.ChromeWindowSize,
+		c.ChromeLanguage, c.ChromeHeadless, c.PanelRenderer, c.PanelRendererFallback,
+		c.PanelScaleFactor, c.PaperSize, c.PageMargins, c.PageScale, c.PageRanges,
+		c.PrintBackground, c.Readiness(""), len(c.ReadinessOverrides), c.TabTimeout, c.RenderTimeout,
+		c.PDFTimeout, c.ReportTimeout, c.RetryMaxAttempts, c.RetryInitialBackoff, c.RetryMaxBackoff, c.RetryJitter,
+		c.RetryStatusCodes, c.WorkerQueueSize, c.MaxQueuedJobsPerUser, c.MaxJobsPerUser, c.MaxJobsPerOrg,
 	)
 }
 
+// joinFilters formats the title, type and row filters of panels.
+func joinFilters(unset string, titles, types, rows []string) string {
+	if len(titles) == 0 && len(types) == 0 && len(rows) == 0 {
+		return unset
+	}
+
+	return strings.Join(titles, ",") + "|" + strings.Join(types, ",") + "|" + strings.Join(rows, ",")
+}
+
+// Validate returns an error if the config contains invalid values.
+func (c *Config) Validate() error {
+	if !slices.Contains([]string{"off", "fail", "annotate", "skip"}, c.PanelErrorPolicy) {
+		return fmt.Errorf("invalid panel error policy %q: must be one of off, fail, annotate or skip", c.PanelErrorPolicy)
+	}
+
+	if c.CompareTo != "" && !timeShiftRegex.MatchString(c.CompareTo) {
+		return fmt.Errorf("invalid compare to %q: must be a time shift like -7d", c.CompareTo)
+	}
+
+	if !slices.Contains([]string{"side-by-side", "stacked"}, c.CompareLayout) {
+		return fmt.Errorf("invalid compare layout %q: must be one of side-by-side or stacked", c.CompareLayout)
+	}
+
+	if c.TableMaxRows < 0 || c.TableLandscapeColumns < 0 {
+		return fmt.Errorf("invalid table options: max rows %d and landscape columns %d must not be negative",
+			c.TableMaxRows, c.TableLandscapeColumns)
+	}
+
+	if !slices.Contains([]string{"image-renderer", "browser"}, c.PanelRenderer) {
+		return fmt.Errorf("invalid panel renderer %q: must be one of image-renderer or browser", c.PanelRenderer)
+	}
+
+	if _, err := c.PageSetup(); err != nil {
+		return err
+	}
+
+	if err := c.Readiness("").validate(); err != nil {
+		return err
+	}
+
+	for uid := range c.ReadinessOverrides {
+		if err := c.Readiness(uid).validate(); err != nil {
+			return fmt.Errorf("dashboard %s: %w", uid, err)
+		}
+	}
+
+	if err := c.validateTimeouts(); err != nil {
+		return err
+	}
+
+	if err := c.RetryPolicy().validate(); err != nil {
+		return err
+	}
+
+	if c.PanelScaleFactor <= 0 || c.PanelScaleFactor > 4 {
+		return fmt.Errorf("invalid panel scale factor %v: must be greater than 0 and at most 4", c.PanelScaleFactor)
+	}
+
+	if !slices.Contains([]string{"round-robin", "least-busy"}, c.RemoteChromeBalancing) {
+		return fmt.Errorf("invalid remote chrome balancing %q: must be one of round-robin or least-busy", c.RemoteChromeBalancing)
+	}
+
+	if _, err := c.RemoteChromeHeaderValues(); err != nil {
+		return err
+	}
+
+	if _, err := c.RemoteChromeTLSConfig(); err != nil {
+		return err
+	}
+
+	// Options of the local browser are irrelevant, if remote browsers are used.
+	if c.RemoteChromeURL == "" {
+		if err := c.validateLocalChrome(); err != nil {
+			return err
+		}
+	}
+
+	if c.WorkerQueueSize < 1 {
+		return fmt.Errorf("invalid worker queue size %d: must be at least 1", c.WorkerQueueSize)
+	}
+
+	if c.MaxQueuedJobsPerUser < 0 {
+		return fmt.Errorf("invalid max queued jobs per user %d: must not be negative", c.MaxQueuedJobsPerUser)
+	}
+
+	if c.MaxJobsPerUser < 0 || c.MaxJobsPerOrg < 0 {
+		return fmt.Errorf("invalid max jobs: per user %d and per org %d must not be negative",
+			c.MaxJobsPerUser, c.MaxJobsPerOrg)
+	}
+
+	if c.TabMaxUses < 0 {
+		return fmt.Errorf("invalid tab max uses %d: must not be negative", c.TabMaxUses)
+	}
+
+	for _, p := range c.StatsPercentiles {
+		if p < 0 || p > 100 {
+			return fmt.Errorf("invalid stats percentile %v: must be between 0 and 100", p)
+		}
+	}
+
+	for _, patterns := range [][]string{
+		c.IncludePanelTitles, c.ExcludePanelTitles, c.IncludeRowTitles, c.ExcludeRowTitles,
+	} {
+		for _, pattern := range patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("invalid panel filter %q: %w", pattern, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateLocalChrome validates the options of the local browser, so that a
+// misconfigured browser fails at startup rather than on the first report.
+func (c *Config) validateLocalChrome() error {
+	if c.ChromeExecPath != "" {
+		info, err := os.Stat(c.ChromeExecPath)
+		if err != nil {
+			return fmt.Errorf("invalid chrome exec path: %w", err)
+		}
+
+		if info.IsDir() || info.Mode().Perm()&0o111 == 0 {
+			return fmt.Errorf("invalid chrome exec path %q: must be an executable file", c.ChromeExecPath)
+		}
+	}
+
+	if _, err := c.ChromeFlagValues(); err != nil {
+		return err
+	}
+
+	if c.ChromeProxyServer != "" {
+		u, err := url.Parse(c.ChromeProxyServer)
+		if err != nil || u.Host == "" || !slices.Contains([]string{"http", "https", "socks4", "socks5"}, u.Scheme) {
+			return fmt.Errorf("invalid chrome proxy server %q: must be like http://host:port or socks5://host:port",
+				c.ChromeProxyServer)
+		}
+	}
+
+	if c.ChromeUserDataDir != "" && !filepath.IsAbs(c.ChromeUserDataDir) {
+		return fmt.Errorf("invalid chrome user data dir %q: must be an absolute path", c.ChromeUserDataDir)
+	}
+
+	if _, _, err := c.ChromeWindowDimensions(); err != nil {
+		return err
+	}
+
+	if c.ChromeLanguage != "" && !languageRegex.MatchString(c.ChromeLanguage) {
+		return fmt.Errorf("invalid chrome language %q: must be a language tag like en-US", c.ChromeLanguage)
+	}
+
+	return nil
+}
+
+// ChromeFlagValues returns the extra command line flags of the local browser, which are
+// configured like "name=value" or "name". Values true and false switch flags on or off,
+// e.g., to remove default flags like disable-gpu.
+func (c *Config) ChromeFlagValues() (map[string]any, error) {
+	flags := make(map[string]any, len(c.ChromeFlags))
+
+	for _, flag := range c.ChromeFlags {
+		name, value, ok := strings.Cut(strings.TrimLeft(strings.TrimSpace(flag), "-"), "=")
+		if name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid chrome flag %q: must be like name=value or name", flag)
+		}
+
+		switch b, err := strconv.ParseBool(value); {
+		case !ok:
+			flags[name] = true
+		case err == nil:
+			flags[name] = b
+		default:
+			flags[name] = value
+		}
+	}
+
+	return flags, nil
+}
+
+// ChromeWindowDimensions returns the width and height of the window size of the local
+// browser, which is configured like 1920x1080. Returns zeros, if it is not set.
+func (c *Config) ChromeWindowDimensions() (int, int, error) {
+	if c.ChromeWindowSize == "" {
+		return 0, 0, nil
+	}
+
+	match := windowSizeRegex.FindStringSubmatch(c.ChromeWindowSize)
+	if match == nil {
+		return 0, 0, fmt.Errorf("invalid chrome window size %q: must be like 1920x1080", c.ChromeWindowSize)
+	}
+
+	width, _ := strconv.Atoi(match[1])
+	height, _ := strconv.Atoi(match[2])
+
+	if width == 0 || height == 0 {
+		return 0, 0, fmt.Errorf("invalid chrome window size %q: width and height must be positive", c.ChromeWindowSize)
+	}
+
+	return width, height, nil
+}
+
+// RemoteChromeURLs returns the comma separated URLs of the remote chrome instances.
+func (c *Config) RemoteChromeURLs() []string {
+	var urls []string
+
+	for _, u := range strings.Split(c.RemoteChromeURL, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+
+	return urls
+}
+
+// RemoteChromeHeaderValues returns the headers for the remote chrome instances, which
+// are configured as lines like "Name: value".
+func (c *Config) RemoteChromeHeaderValues() (http.Header, error) {
+	header := http.Header{}
+
+	for _, line := range strings.Split(c.RemoteChromeHeaders, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid remote chrome header %q: must be like Name: value", name)
+		}
+
+		header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	return header, nil
+}
+
+// RemoteChromeTLSConfig returns the TLS config for the remote chrome instances with the
+// configured CA and client certificate. Returns nil, if none of them is configured.
+func (c *Config) RemoteChromeTLSConfig() (*tls.Config, error) {
+	if c.RemoteChromeTLSCACert == "" && c.RemoteChromeTLSClientCert == "" && c.RemoteChromeTLSClientKey == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if c.RemoteChromeTLSCACert != "" {
+		tlsConfig.RootCAs = x509.NewCertPool()
+
+		if !tlsConfig.RootCAs.AppendCertsFromPEM([]byte(c.RemoteChromeTLSCACert)) {
+			return nil, errors.New("invalid remote chrome CA certificate: no PEM encoded certificate found")
+		}
+	}
+
+	if c.RemoteChromeTLSClientCert != "" || c.RemoteChromeTLSClientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(c.RemoteChromeTLSClientCert), []byte(c.RemoteChromeTLSClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid remote chrome client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// CompareTime shifts the time into the comparison period. Like the time shift of Grafana
+// panels, the shift always goes back in time. Returns the time as is, if comparison is
+// disabled.
+func (c *Config) CompareTime(t time.Time) time.Time {
+	match := timeShiftRegex.FindStringSubmatch(c.CompareTo)
+	if match == nil {
+		return t
+	}
+
+	n, _ := strconv.Atoi(match[1])
+
+	switch match[2] {
+	case "s":
+		return t.Add(-time.Duration(n) * time.Second)
+	case "m":
+		return t.Add(-time.Duration(n) * time.Minute)
+	case "h":
+		return t.Add(-time.Duration(n) * time.Hour)
+	case "d":
+		return t.AddDate(0, 0, -n)
+	case "w":
+		return t.AddDate(0, 0, -7*n)
+	case "M":
+		return addMonths(t, -n)
+	default: // y
+		return addMonths(t, -12*n)
+	}
+}
+
+// addMonths adds months to the time. Unlike AddDate, days beyond the end of the
+// resulting month are clamped to its last day, e.g., one month before March 31st is
+// the last day of February.
+func addMonths(t time.Time, months int) time.Time {
+	shifted := t.AddDate(0, months, 0)
+	if shifted.Day() != t.Day() {
+		shifted = shifted.AddDate(0, 0, -shifted.Day())
+	}
+
+	return shifted
+}
+
 // Load loads the plugin settings from data sent by provisioned config or from Grafana UI.
 func Load(ctx context.Context, settings backend.AppInstanceSettings) (Config, error) {
 	config := DefaultConfig
 
+	// Unmarshalling reuses the backing array of slices, so do not share it with the defaults
+	config.StatsPercentiles = slices.Clone(DefaultConfig.StatsPercentiles)
+	config.RetryStatusCodes = slices.Clone(DefaultConfig.RetryStatusCodes)
+
 	// Fetch token, if configured in SecureJSONData
 	if settings.DecryptedSecureJSONData != nil {
 		if saToken, ok := settings.DecryptedSecureJSONData[SaToken]; ok && saToken != "" {
 			config.Token = saToken
 		}
+
+		config.RemoteChromeToken = settings.DecryptedSecureJSONData[RemoteChromeToken]
+		config.RemoteChromeHeaders = settings.DecryptedSecureJSONData[RemoteChromeHeaders]
+		config.RemoteChromeTLSCACert = settings.DecryptedSecureJSONData[RemoteChromeTLSCACert]
+		config.RemoteChromeTLSClientCert = settings.DecryptedSecureJSONData[RemoteChromeTLSClientCert]
+		config.RemoteChromeTLSClientKey = settings.DecryptedSecureJSONData[RemoteChromeTLSClientKey]
 	}
 
 	// Update plugin settings defaults
@@ -140,5 +594,9 @@ func Load(ctx context.Context, settings backend.AppInstanceSettings) (Config, er
 
 	config.HTTPClientOptions.TLS = &httpclient.TLSOptions{InsecureSkipVerify: config.SkipTLSCheck}
 
+	if err := config.Validate(); err != nil {
+		return Config{}, fmt.Errorf("error in plugin settings: %w", err)
+	}
+
 	return config, nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
@@ -53,6 +54,31 @@ func TestSettings(t *testing.T) {
 				conf.Layout = "grid"
 				conf.Token = "superSecretToken"
 
+				return conf
+			}(),
+		},
+		{
+			"panel filters",
+			`{"includePanelTitles": ["^CPU", "Memory"], "excludePanelTypes": ["text"], "excludeRowTitles": ["Debug"]}`,
+			nil,
+			func() config.Config {
+				conf := config.DefaultConfig
+				conf.IncludePanelTitles = []string{"^CPU", "Memory"}
+				conf.ExcludePanelTypes = []string{"text"}
+				conf.ExcludeRowTitles = []string{"Debug"}
+
+				return conf
+			}(),
+		},
+		{
+			"panel stats",
+			`{"panelStats": true, "statsPercentiles": [90, 99.9]}`,
+			nil,
+			func() config.Config {
+				conf := config.DefaultConfig
+				conf.PanelStats = true
+				conf.StatsPercentiles = []float64{90, 99.9}
+
 				return conf
 			}(),
 		},
@@ -162,3 +188,311 @@ func TestSettingsUsingConfigAndOverridingEnvVars(t *testing.T) {
 	assert.Equal(t, 2, conf.MaxRenderWorkers)
 	assert.Equal(t, "ws://localhost:5333", conf.RemoteChromeURL)
 }
+
+func TestSettingsUsingPanelFilterEnvVars(t *testing.T) {
+	// Setup env vars
+	t.Setenv("GF_REPORTER_PLUGIN_INCLUDE_PANEL_TITLES", "^CPU (user|system)$;Memory{1,2}")
+	t.Setenv("GF_REPORTER_PLUGIN_EXCLUDE_PANEL_TYPES", "text,news")
+
+	conf, err := config.Load(context.Background(), backend.AppInstanceSettings{JSONData: json.RawMessage(`{}`)})
+
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"^CPU (user|system)$", "Memory{1,2}"}, conf.IncludePanelTitles)
+	assert.Equal(t, []string{"text", "news"}, conf.ExcludePanelTypes)
+}
+
+func TestSettingsWithInvalidPanelFilter(t *testing.T) {
+	t.Parallel()
+
+	_, err := config.Load(context.Background(), backend.AppInstanceSettings{
+		JSONData: json.RawMessage(`{"excludeRowTitles": ["(unclosed"]}`),
+	})
+
+	require.Error(t, err)
+}
+
+func TestSettingsWithInvalidStatsPercentile(t *testing.T) {
+	t.Parallel()
+
+	_, err := config.Load(context.Background(), backend.AppInstanceSettings{
+		JSONData: json.RawMessage(`{"statsPercentiles": [95, 101]}`),
+	})
+
+	require.Error(t, err)
+}
+
+func TestSettingsWithInvalidPanelErrorPolicy(t *testing.T) {
+	t.Parallel()
+
+	_, err := config.Load(context.Background(), backend.AppInstanceSettings{
+		JSONData: json.RawMessage(`{"panelErrorPolicy": "ignore"}`),
+	})
+
+	require.Error(t, err)
+}
+
+func TestSettingsWithInvalidCompareTo(t *testing.T) {
+	t.Parallel()
+
+	_, err := config.Load(context.Background(), backend.AppInstanceSettings{
+		JSONData: json.RawMessage(`{"compareTo": "last week"}`),
+	})
+
+	require.Error(t, err)
+}
+
+func TestSettingsWithInvalidTabMaxUses(t *testing.T) {
+	t.Parallel()
+
+	_, err := config.Load(context.Background(), backend.AppInstanceSettings{
+		JSONData: json.RawMessage(`{"tabMaxUses": -1}`),
+	})
+
+	require.Error(t, err)
+}
+
+func TestCompareTime(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		compareTo string
+		expected  time.Time
+	}{
+		{"", now},
+		{"-30s", time.Date(2024, 3, 31, 11, 59, 30, 0, time.UTC)},
+		{"-2h", time.Date(2024, 3, 31, 10, 0, 0, 0, time.UTC)},
+		{"-7d", time.Date(2024, 3, 24, 12, 0, 0, 0, time.UTC)},
+		{"1w", time.Date(2024, 3, 24, 12, 0, 0, 0, time.UTC)},
+		{"-1M", time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{"-1y", time.Date(2023, 3, 31, 12, 0, 0, 0, time.UTC)},
+	} {
+		t.Run(tc.compareTo, func(t *testing.T) {
+			t.Parallel()
+
+			conf := config.Config{CompareTo: tc.compareTo}
+
+			assert.Equal(t, tc.expected, conf.CompareTime(now))
+		})
+	}
+}
+
+func TestRemoteChromeURLs(t *testing.T) {
+	t.Parallel()
+
+	conf := config.Config{RemoteChromeURL: "ws://chrome-0:9222, ws://chrome-1:9222,,"}
+
+	assert.Equal(t, []string{"ws://chrome-0:9222", "ws://chrome-1:9222"}, conf.RemoteChromeURLs())
+}
+
+func TestSettingsWithInvalidRemoteChromeBalancing(t *testing.T) {
+	t.Parallel()
+
+	_, err := config.Load(context.Background(), backend.AppInstanceSettings{
+		JSONData: json.RawMessage(`{"remoteChromeBalancing": "random"}`),
+	})
+
+	require.Error(t, err)
+}
+
+func TestSettingsWithInvalidRemoteChromeSecrets(t *testing.T) {
+	t.Parallel()
+
+	for name, secrets := range map[string]map[string]string{
+		"header without value": {config.RemoteChromeHeaders: "X-Tenant"},
+		"invalid CA":           {config.RemoteChromeTLSCACert: "not a certificate"},
+		"client cert only":     {config.RemoteChromeTLSClientCert: "not a certificate"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := config.Load(context.Background(), backend.AppInstanceSettings{
+				JSONData:                json.RawMessage(`{"remoteChromeUrl": "http://chrome:9222"}`),
+				DecryptedSecureJSONData: secrets,
+			})
+
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestRemoteChromeHeaderValues(t *testing.T) {
+	t.Parallel()
+
+	conf := config.Config{RemoteChromeHeaders: "X-Tenant: reports\n\nCookie: a=b; c=d\n"}
+
+	header, err := conf.RemoteChromeHeaderValues()
+	require.NoError(t, err)
+	assert.Equal(t, "reports", header.Get("X-Tenant"))
+	assert.Equal(t, "a=b; c=d", header.Get("Cookie"))
+}
+
+func TestSettingsWithInvalidChromeOptions(t *testing.T) {
+	t.Parallel()
+
+	for name, jsonData := range map[string]string{
+		"missing exec path":    `{"chromeExecPath": "/does/not/exist/chrome"}`,
+		"exec path is dir":     `{"chromeExecPath": "/"}`,
+		"empty flag":           `{"chromeFlags": ["--"]}`,
+		"proxy without scheme": `{"chromeProxyServer": "proxy:3128"}`,
+		"relative user data":   `{"chromeUserDataDir": "profile"}`,
+		"invalid window size":  `{"chromeWindowSize": "1920"}`,
+		"zero window size":     `{"chromeWindowSize": "0x1080"}`,
+		"invalid language":     `{"chromeLanguage": "en_US"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := config.Load(context.Background(), backend.AppInstanceSettings{
+				JSONData: json.RawMessage(jsonData),
+			})
+
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestSettingsWithChromeOptionsOfRemoteChrome(t *testing.T) {
+	t.Parallel()
+
+	// Options of the local browser are not validated, if remote browsers are used.
+	_, err := config.Load(context.Background(), backend.AppInstanceSettings{
+		JSONData: json.RawMessage(`{"remoteChromeUrl": "http://chrome:9222", "chromeExecPath": "/does/not/exist/chrome"}`),
+	})
+
+	require.NoError(t, err)
+}
+
+func TestChromeFlagValues(t *testing.T) {
+	t.Parallel()
+
+	conf := config.Config{ChromeFlags: []string{"--disable-features=Translate,MediaRouter", "disable-gpu=false", "kiosk"}}
+
+	flags, err := conf.ChromeFlagValues()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"disable-features": "Translate,MediaRouter",
+		"disable-gpu":      false,
+		"kiosk":            true,
+	}, flags)
+}
+
+func TestChromeWindowDimensions(t *testing.T) {
+	t.Parallel()
+
+	conf := config.Config{ChromeWindowSize: "1920x1080"}
+
+	width, height, err := conf.ChromeWindowDimensions()
+	require.NoError(t, err)
+	assert.Equal(t, 1920, width)
+	assert.Equal(t, 1080, height)
+}
+
+func TestSettingsWithInvalidPanelRenderer(t *testing.T) {
+	t.Parallel()
+
+	for name, jsonData := range map[string]string{
+		"unknown renderer":   `{"panelRenderer": "phantomjs"}`,
+		"zero scale factor":  `{"panelScaleFactor": 0}`,
+		"large scale factor": `{"panelScaleFactor": 8}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := config.Load(context.Background(), backend.AppInstanceSettings{
+				JSONData: json.RawMessage(jsonData),
+			})
+
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	t.Parallel()
+
+	conf, err := config.Load(context.Background(), backend.AppInstanceSettings{
+		JSONData: json.RawMessage(`{
+			"readinessSettleDelay": "2s",
+			"readinessOverrides": {"slow": {"settleDelay": "5s", "timeout": "2m"}}
+		}`),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, config.Readiness{
+		Strategy:    config.ReadinessGrafana,
+		SettleDelay: config.Duration(2 * time.Second),
+		Timeout:     config.Duration(30 * time.Second),
+	}, conf.Readiness("other"))
+
+	assert.Equal(t, config.Readiness{
+		Strategy:    config.ReadinessGrafana,
+		SettleDelay: config.Duration(5 * time.Second),
+		Timeout:     config.Duration(2 * time.Minute),
+	}, conf.Readiness("slow"))
+}
+
+func TestSettingsUsingReadinessEnvVars(t *testing.T) {
+	t.Setenv("GF_REPORTER_PLUGIN_READINESS_STRATEGY", "networkIdle")
+	t.Setenv("GF_REPORTER_PLUGIN_READINESS_SETTLE_DELAY", "1500ms")
+
+	conf, err := config.Load(context.Background(), backend.AppInstanceSettings{JSONData: json.RawMessage(`{}`)})
+	require.NoError(t, err)
+
+	assert.Equal(t, config.ReadinessNetworkIdle, conf.ReadinessStrategy)
+	assert.Equal(t, config.Duration(1500*time.Millisecond), conf.ReadinessSettleDelay)
+}
+
+func TestSettingsWithInvalidReadiness(t *testing.T) {
+	t.Parallel()
+
+	for name, jsonData := range map[string]string{
+		"unknown strategy":        `{"readinessStrategy": "load"}`,
+		"invalid settle delay":    `{"readinessSettleDelay": "soon"}`,
+		"negative settle delay":   `{"readinessSettleDelay": "-1s"}`,
+		"zero timeout":            `{"readinessTimeout": "0s"}`,
+		"unknown override":        `{"readinessOverrides": {"uid": {"strategy": "load"}}}`,
+		"negative override delay": `{"readinessOverrides": {"uid": {"settleDelay": "-1s"}}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := config.Load(context.Background(), backend.AppInstanceSettings{
+				JSONData: json.RawMessage(jsonData),
+			})
+
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestSettingsWithInvalidTimeoutsAndRetries(t *testing.T) {
+	t.Parallel()
+
+	for name, jsonData := range map[string]string{
+		"zero tab timeout":           `{"tabTimeout": "0s"}`,
+		"negative render timeout":    `{"renderTimeout": "-1m"}`,
+		"negative report timeout":    `{"reportTimeout": "-1m"}`,
+		"no attempts":                `{"retryMaxAttempts": 0}`,
+		"backoff greater than max":   `{"retryInitialBackoff": "2m", "retryMaxBackoff": "1m"}`,
+		"jitter greater than 1":      `{"retryJitter": 1.5}`,
+		"successful status code":     `{"retryStatusCodes": [200]}`,
+		"unknown status code":        `{"retryStatusCodes": [999]}`,
+		"empty worker queue":         `{"workerQueueSize": 0}`,
+		"negative max queued jobs":   `{"maxQueuedJobsPerUser": -1}`,
+		"negative max jobs per user": `{"maxJobsPerUser": -1}`,
+		"negative max jobs per org":  `{"maxJobsPerOrg": -1}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := config.Load(context.Background(), backend.AppInstanceSettings{
+				JSONData: json.RawMessage(jsonData),
+			})
+
+			require.Error(t, err)
+		})
+	}
+}
@@ -1,15 +1,24 @@
 package dashboard
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
 )
 
-func (d *Dashboard) fetchAPI(ctx context.Context) (APIDashboardData, error) {
+// fetchAPI fetches the dashboard model from the Grafana API. Dashboards of schema v2
+// are not available through the classic API and they are fetched from the new one.
+// When the dashVersion parameter is set, the given version of the model is fetched.
+func (d *Dashboard) fetchAPI(ctx context.Context, expandRows bool) (APIDashboardData, error) {
+	if version := d.values.Get("dashVersion"); version != "" {
+		return d.fetchAPIVersion(ctx, version, expandRows)
+	}
+
 	dashURL, err := url.Parse(d.grafanaBaseURL)
 	if err != nil {
 		return APIDashboardData{}, fmt.Errorf("error parsing Grafana base URL: %w", err)
@@ -40,6 +49,13 @@ func (d *Dashboard) fetchAPI(ctx context.Context) (APIDashboardData, error) {
 		}
 	}(resp.Body)
 
+	// Grafana refuses to return dashboards of schema v2 via the classic API
+	if resp.StatusCode == http.StatusNotAcceptable {
+		d.logger.Debug("dashboard not available via classic API, trying schema v2", "uid", d.uid)
+
+		return d.fetchAPIV2(ctx, expandRows)
+	}
+
 	// Check if the response status code is not 200
 	if resp.StatusCode != http.StatusOK {
 		// ignore the response body error if the status code is not 200
@@ -63,3 +79,76 @@ func (d *Dashboard) fetchAPI(ctx context.Context) (APIDashboardData, error) {
 
 	return data.Dashboard, nil
 }
+
+// apiURL returns the URL of the Grafana API endpoint joined from the given path elements.
+func (d *Dashboard) apiURL(elem ...string) (*url.URL, error) {
+	apiURL, err := url.Parse(d.grafanaBaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing Grafana base URL: %w", err)
+	}
+
+	return apiURL.JoinPath(elem...), nil
+}
+
+// doJSON sends a request with an optional JSON body to the Grafana API and decodes the
+// JSON response into target. Responses with a status code other than statusCodes are errors.
+// By default, only 200 OK is accepted.
+func (d *Dashboard) doJSON(ctx context.Context, method string, apiURL *url.URL, body any, target any, statusCodes ...int) error {
+	var reqBody io.Reader
+
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error encoding request body: %w", err)
+		}
+
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, apiURL.String(), reqBody)
+	if err != nil {
+		return fmt.Errorf("error creating request for %s: %w", apiURL.String(), err)
+	}
+
+	// Add the Authorization header
+	req.Header.Add("Authorization", "Bearer "+d.saToken)
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := d.httpClient.Do(req) //nolint:bodyclose //https://github.com/timakin/bodyclose/issues/30
+	if err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+
+	// Close the response body
+	defer func(body io.ReadCloser) {
+		if err := body.Close(); err != nil {
+			d.logger.Error("error closing response body", "error", err)
+		}
+	}(resp.Body)
+
+	if len(statusCodes) == 0 {
+		statusCodes = []int{http.StatusOK}
+	}
+
+	if !slices.Contains(statusCodes, resp.StatusCode) {
+		// ignore the response body error if the status code is not expected
+		body, _ := io.ReadAll(resp.Body)
+
+		return fmt.Errorf(
+			"%w: URL: %s. Status: %s, message: %s",
+			ErrGrafanaAPIHTTPError,
+			apiURL.String(),
+			resp.Status,
+			string(body),
+		)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("error decoding response body: %w", err)
+	}
+
+	return nil
+}
@@ -48,7 +48,7 @@ Promise.all(promises);`
 		"transform": e.style.transform,
 		"id": parseInt(e.getAttribute("data-panelid")),
 		"type": props.children[0].props.panel.type,
-		"title": e.querySelector('h2')?.innerText,
+		"title": e.querySelector('h2')?.innerText ?? e.querySelector('[data-testid*="dashboard-row-title"]')?.innerText,
     }
 })`
 
@@ -71,7 +71,7 @@ let output = {
 	selInspectPanelDataTabApplyTransformationsToggle = `div[data-testid="dataOptions"] input:not(#excel-toggle):not(#formatted-data-toggle) + label`
 )
 
-func (d *Dashboard) fetchBrowser(ctx context.Context, expandRows bool) (BrowserData, error) {
+func (d *Dashboard) fetchBrowser(ctx context.Context, expandRows bool, withPanels bool) (BrowserData, error) {
 	dashURL, err := url.Parse(d.grafanaBaseURL)
 	if err != nil {
 		return BrowserData{}, fmt.Errorf("error parsing Grafana base URL: %w", err)
@@ -84,7 +84,13 @@ func (d *Dashboard) fetchBrowser(ctx context.Context, expandRows bool) (BrowserD
 
 	dashURL.RawQuery = dashURLValues.Encode()
 
-	browserData, err := d.fetchPanelDataFromBrowser(ctx, dashURL.String(), expandRows)
+	var browserData BrowserData
+
+	err = chrome.RetryOnCrash(ctx, d.chromeInstance, d.logger, func() error {
+		browserData, err = d.fetchPanelDataFromBrowser(ctx, dashURL.String(), expandRows, withPanels)
+
+		return err
+	})
 	if err != nil {
 		return BrowserData{}, fmt.Errorf("error fetching browser data: %w", err)
 	}
@@ -96,69 +102,82 @@ func (d *Dashboard) fetchBrowser(ctx context.Context, expandRows bool) (BrowserD
 // It fetch data of all grafana panels, including repeating one, which are not visible via API.
 // Additionally, it fetches the absolute time range of the dashboard.
 // Asking for absolute time range avoids additional logic to parse time ranges like 'now-30d/M'.
+// When withPanels is false, only the time range is fetched.
 //
 //nolint:cyclop
-func (d *Dashboard) fetchPanelDataFromBrowser(_ context.Context, dashURL string, expandRows bool) (BrowserData, error) {
-	tab := d.chromeInstance.NewTab(d.logger, d.conf)
-	tab.WithTimeout(1 * time.Minute)
+func (d *Dashboard) fetchPanelDataFromBrowser(ctx context.Context, dashURL string, expandRows bool, withPanels bool) (_ BrowserData, err error) {
+	tab := d.chromeInstance.NewTab(ctx, d.logger, d.conf)
+	tab.WithTimeout(time.Duration(d.conf.TabTimeout))
 
 	defer tab.Close(d.logger)
 
+	// Attach the console messages, exceptions and failed requests of the page to errors
+	defer func() { err = tab.AttachDiagnostics(ctx, err) }()
+
 	// Set the OAuth token in the headers
 	headers := map[string]any{backend.OAuthIdentityTokenHeaderName: "Bearer " + d.saToken}
 
 	d.logger.Debug("Navigating to dashboard via browser", "url", dashURL)
 
-	// Navigate to the dashboard
-	if err := tab.NavigateAndWaitFor(dashURL, headers, "networkIdle"); err != nil {
-		return BrowserData{}, fmt.Errorf("NavigateAndWaitFor: %w", err)
+	readiness := d.conf.Readiness(d.uid)
+
+	// Navigate to the dashboard and wait until its panels are loaded
+	if err := tab.NavigateAndWaitReady(dashURL, headers, readiness); err != nil {
+		return BrowserData{}, fmt.Errorf("NavigateAndWaitReady: %w", err)
 	}
 
-	// Expand all rows, if requested
-	if expandRows {
-		if err := tab.RunWithTimeout(5*time.Second, chromedp.Evaluate(javascriptExpandRows, nil)); err != nil {
-			return BrowserData{}, fmt.Errorf("error uncollapsing rows: %w", err)
+	var dashboardData BrowserData
+
+	// Fetch dashboard data
+	if withPanels {
+		// Expand all rows, if requested
+		if expandRows {
+			if err := tab.Run(chromedp.Evaluate(javascriptExpandRows, nil)); err != nil {
+				return BrowserData{}, fmt.Errorf("error uncollapsing rows: %w", err)
+			}
 		}
-	}
 
-	// Check if the page has a scrollbar
-	if err := tab.RunWithTimeout(5*time.Second, chromedp.WaitReady(selPageScrollbar, chromedp.ByQuery)); err != nil {
-		return BrowserData{}, fmt.Errorf("error waiting for #page-scrollbar: %w", err)
-	}
+		// Check if the page has a scrollbar
+		if err := tab.Run(chromedp.WaitReady(selPageScrollbar, chromedp.ByQuery)); err != nil {
+			return BrowserData{}, fmt.Errorf("error waiting for #page-scrollbar: %w", err)
+		}
 
-	if err := tab.RunWithTimeout(5*time.Second, chromedp.Evaluate(javascriptScrollToBottom, nil, chrome.WithAwaitPromise)); err != nil {
-		return BrowserData{}, fmt.Errorf("error scrolling to bottom: %w", err)
-	}
+		if err := tab.Run(chromedp.Evaluate(javascriptScrollToBottom, nil, chrome.WithAwaitPromise)); err != nil {
+			return BrowserData{}, fmt.Errorf("error scrolling to bottom: %w", err)
+		}
 
-	// Fetch dashboard data
-	var dashboardData BrowserData
+		// Expanded rows and panels scrolled into view load lazily
+		if err := tab.WaitReady(readiness); err != nil {
+			return BrowserData{}, fmt.Errorf("error waiting for panels after scrolling: %w", err)
+		}
 
-	// JS that will fetch dashboard model
-	if err := tab.RunWithTimeout(30*time.Second, chromedp.Evaluate(javascriptPanelData, &dashboardData.PanelData)); err != nil {
-		return BrowserData{}, fmt.Errorf("error fetching panel data: %w", err)
-	}
+		// JS that will fetch dashboard model
+		if err := tab.Run(chromedp.Evaluate(javascriptPanelData, &dashboardData.PanelData)); err != nil {
+			return BrowserData{}, fmt.Errorf("error fetching panel data: %w", err)
+		}
 
-	if len(dashboardData.PanelData) == 0 {
-		return BrowserData{}, ErrJavaScriptReturnedNoPanels
+		if len(dashboardData.PanelData) == 0 {
+			return BrowserData{}, ErrJavaScriptReturnedNoPanels
+		}
 	}
 
 	// Check if the page has a time picker button
-	if err := tab.RunWithTimeout(5*time.Second, chromedp.WaitReady(selTimePickerButton, chromedp.ByQuery)); err != nil {
-		return BrowserData{}, fmt.Errorf("error waiting for #page-scrollbar: %w", err)
+	if err := tab.Run(chromedp.WaitReady(selTimePickerButton, chromedp.ByQuery)); err != nil {
+		return BrowserData{}, fmt.Errorf("error waiting for time picker: %w", err)
 	}
 
 	// To get the time range, we need to hover over the time picker button
-	if err := tab.RunWithTimeout(5*time.Second, chromedp.Evaluate(javascriptTimePickerMouseEnter, nil)); err != nil {
+	if err := tab.Run(chromedp.Evaluate(javascriptTimePickerMouseEnter, nil)); err != nil {
 		return BrowserData{}, fmt.Errorf("error mouse entering time picker: %w", err)
 	}
 
 	// Check if the page has a time picker button
-	if err := tab.RunWithTimeout(5*time.Second, chromedp.WaitReady(selTimePickerTimeRangeToolTip, chromedp.ByQuery)); err != nil {
+	if err := tab.Run(chromedp.WaitReady(selTimePickerTimeRangeToolTip, chromedp.ByQuery)); err != nil {
 		return BrowserData{}, fmt.Errorf("error waiting for time picker tooltip: %w", err)
 	}
 
 	// Fetch the time range data
-	if err := tab.RunWithTimeout(5*time.Second, chromedp.Evaluate(javascriptGetTimeRange, &dashboardData.TimeRange)); err != nil {
+	if err := tab.Run(chromedp.Evaluate(javascriptGetTimeRange, &dashboardData.TimeRange)); err != nil {
 		return BrowserData{}, fmt.Errorf("error fetching time range: %w", err)
 	}
 
@@ -184,15 +203,21 @@ func (d *Dashboard) FetchTable(ctx context.Context, panel Panel) (PanelTable, er
 
 	dashURL.RawQuery = dashURLValues.Encode()
 
-	data, err := d.fetchTableData(ctx, dashURL.String())
+	var data PanelTableData
+
+	err = chrome.RetryOnCrash(ctx, d.chromeInstance, d.logger, func() error {
+		data, err = d.fetchTableData(ctx, dashURL.String())
+
+		return err
+	})
 	if err != nil {
 		return PanelTable{}, fmt.Errorf("error fetching browser data: %w", err)
 	}
 
-	return PanelTable{
+	return ApplyTableOptions(PanelTable{
 		Title: panel.Title,
 		Data:  data,
-	}, nil
+	}, d.tableOptions(panel)), nil
 }
 
 // fetchTableData fetches the CSV data for a panel using a browser.
@@ -202,20 +227,23 @@ func (d *Dashboard) FetchTable(ctx context.Context, panel Panel) (PanelTable, er
 // The browser will navigate to the panel URL, click the "Download CSV" button, and capture the CSV data.
 //
 //nolint:cyclop
-func (d *Dashboard) fetchTableData(_ context.Context, panelURL string) (PanelTableData, error) {
-	tab := d.chromeInstance.NewTab(d.logger, d.conf)
-	tab.WithTimeout(1 * time.Minute)
+func (d *Dashboard) fetchTableData(ctx context.Context, panelURL string) (_ PanelTableData, err error) {
+	tab := d.chromeInstance.NewTab(ctx, d.logger, d.conf)
+	tab.WithTimeout(time.Duration(d.conf.TabTimeout))
 
 	defer tab.Close(d.logger)
 
+	// Attach the console messages, exceptions and failed requests of the page to errors
+	defer func() { err = tab.AttachDiagnostics(ctx, err) }()
+
 	// Set the OAuth token in the headers
 	headers := map[string]any{backend.OAuthIdentityTokenHeaderName: "Bearer " + d.saToken}
 
 	d.logger.Debug("fetch table data via browser", "url", panelURL)
 
-	err := tab.NavigateAndWaitFor(panelURL, headers, "networkIdle")
+	err = tab.NavigateAndWaitReady(panelURL, headers, d.conf.Readiness(d.uid))
 	if err != nil {
-		return nil, fmt.Errorf("NavigateAndWaitFor: %w", err)
+		return nil, fmt.Errorf("NavigateAndWaitReady: %w", err)
 	}
 
 	// this will be used to capture the blob URL of the CSV download
@@ -233,23 +261,30 @@ func (d *Dashboard) fetchTableData(_ context.Context, panelURL string) (PanelTab
 		}
 	})
 
-	task := chromedp.Action(
-		browser.SetDownloadBehavior(browser.SetDownloadBehaviorBehaviorAllowAndName).
+	// The download behavior is set for the browser context of the tab, as tabs are
+	// isolated from each other.
+	var task chromedp.Action = chromedp.ActionFunc(func(ctx context.Context) error {
+		return browser.SetDownloadBehavior(browser.SetDownloadBehaviorBehaviorAllowAndName).
 			WithDownloadPath("/dev/null").
-			WithEventsEnabled(true),
-	)
-	if err = tab.RunWithTimeout(2*time.Second, task); err != nil {
+			WithEventsEnabled(true).
+			WithBrowserContextID(chromedp.FromContext(ctx).BrowserContextID).
+			Do(ctx)
+	})
+	if err = tab.Run(task); err != nil {
 		return nil, fmt.Errorf("error setting download behavior: %w", err)
 	}
 
-	if err = tab.RunWithTimeout(2*time.Second, chromedp.WaitVisible(selDownloadCSVButton, chromedp.ByQuery)); err != nil {
+	if err = tab.Run(chromedp.WaitVisible(selDownloadCSVButton, chromedp.ByQuery)); err != nil {
 		return nil, fmt.Errorf("error waiting for download CSV button: %w", err)
 	}
 
-	if err = tab.RunWithTimeout(2*time.Second, chromedp.Click(selInspectPanelDataTabExpandDataOptions, chromedp.ByQuery)); err != nil {
+	if err = tab.Run(chromedp.Click(selInspectPanelDataTabExpandDataOptions, chromedp.ByQuery)); err != nil {
 		return nil, fmt.Errorf("error clicking on expand data options: %w", err)
 	}
 
+	// The toggle only exists for panels with transformations, so a timeout means that
+	// there is nothing to toggle.
+
 	if err = tab.RunWithTimeout(1*time.Second, chromedp.Click(selInspectPanelDataTabApplyTransformationsToggle, chromedp.ByQuery)); err != nil && !errors.Is(err, context.DeadlineExceeded) {
 		return nil, fmt.Errorf("error clicking on apply transformations toggle: %w", err)
 	}
@@ -301,7 +336,7 @@ func (d *Dashboard) fetchTableData(_ context.Context, panelURL string) (PanelTab
 		chrome.WithAwaitPromise,
 	)
 
-	if err := tab.RunWithTimeout(45*time.Second, task); err != nil {
+	if err := tab.Run(task); err != nil {
 		return nil, fmt.Errorf("error fetching CSV data from URL from browser %s: %w", panelURL, err)
 	}
 
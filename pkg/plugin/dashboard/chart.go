@@ -0,0 +1,530 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Classic palette of Grafana used to color series.
+var seriesColors = []string{
+	"#7EB26D", "#EAB839", "#6ED0E0", "#EF843C", "#E24D42", "#1F78C1", "#BA43A9", "#705DA0",
+	"#508642", "#CCA300", "#447EBC", "#C15C17", "#890F02", "#0A437C", "#6D1F62", "#584477",
+}
+
+// Steps used for the ticks of the time axis.
+var timeSteps = []time.Duration{
+	time.Second, 5 * time.Second, 15 * time.Second, 30 * time.Second,
+	time.Minute, 5 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour,
+	24 * time.Hour, 2 * 24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour, 365 * 24 * time.Hour,
+}
+
+// Margins of the plot area inside the chart.
+const (
+	chartMarginLeft   = 70
+	chartMarginRight  = 10
+	chartMarginTop    = 10
+	chartMarginBottom = 25
+	chartFontSize     = 11
+)
+
+// Draw styles of the chart series.
+const (
+	drawStyleLine   = "line"
+	drawStyleBars   = "bars"
+	drawStylePoints = "points"
+)
+
+// chartStyle describes how series and thresholds of a chart are drawn.
+type chartStyle struct {
+	DrawStyle       string  `json:"drawStyle"`
+	LineWidth       float64 `json:"lineWidth"`
+	FillOpacity     float64 `json:"fillOpacity"`
+	ThresholdsStyle struct {
+		Mode string `json:"mode"`
+	} `json:"thresholdsStyle"`
+}
+
+// chartSeries is a single series of a chart with its color.
+type chartSeries struct {
+	FieldValues
+	Color string
+}
+
+// IsTimeSeries returns true if panel is of type Graph or TimeSeries.
+func (p Panel) IsTimeSeries() bool {
+	return p.Is(Graph) || p.Is(TimeSeries)
+}
+
+// RenderChart queries the data of a time series or graph panel and renders it into
+// a SVG chart with axes, legend and threshold lines. Unlike the PNG of the panel,
+// the chart does not pixelate when zoomed or printed large.
+//
+//nolint:cyclop
+func (d *Dashboard) RenderChart(ctx context.Context, dashData Data, panel Panel) (PanelHTML, error) {
+	frames, err := d.QueryData(ctx, dashData, panel)
+	if err != nil {
+		return PanelHTML{}, err
+	}
+
+	defaults, style := panel.chartConfig()
+
+	fields := numericFields(frames, defaults, "")
+	series := make([]chartSeries, 0, len(fields))
+
+	for i, field := range fields {
+		if field.Times == nil {
+			return PanelHTML{}, fmt.Errorf("%w: field %s has no time", ErrUnsupportedChartData, field.Name)
+		}
+
+		color := seriesColors[i%len(seriesColors)]
+		if defaults.Color.Mode == "fixed" && defaults.Color.FixedColor != "" {
+			color = defaults.Color.FixedColor
+		}
+
+		series = append(series, chartSeries{field, string(Color(color))})
+	}
+
+	width, height := d.panelSize(panel)
+
+	var buf strings.Builder
+
+	// The charts of the compared periods are part of the same report, so the ID of the
+	// clip path must be unique per period as well.
+	clipID := fmt.Sprintf("plot-%d-%d", panel.ID, dashData.TimeRange.FromTime.UnixMilli())
+
+	buf.WriteString(renderSVG(clipID, dashData.TimeRange, series, defaults, style, width, height, d.location()))
+
+	if panel.showLegend() && len(series) > 0 {
+		buf.WriteString(`<div class="chart-legend">`)
+
+		for _, s := range series {
+			fmt.Fprintf(&buf, `<span class="chart-legend-item"><span class="chart-legend-color" style="background-color: %s"></span>%s</span>`,
+				s.Color, html.EscapeString(s.Name))
+		}
+
+		buf.WriteString(`</div>`)
+	}
+
+	d.logger.Debug("rendered chart panel natively", "panel_id", panel.ID, "series", len(series))
+
+	return PanelHTML{
+		Panel: panel,
+		HTML:  template.HTML(buf.String()), //nolint:gosec // all dynamic content is escaped
+	}, nil
+}
+
+// panelSize returns the size of the panel in pixels, same as used for PNG rendering.
+func (d *Dashboard) panelSize(panel Panel) (int, int) {
+	if d.conf.Layout == "grid" {
+		return int(panel.GridPos.W * 100), int(panel.GridPos.H * 36)
+	}
+
+	return 1000, 500
+}
+
+// location returns the time zone of the report.
+func (d *Dashboard) location() *time.Location {
+	if d.conf.TimeZone != "" {
+		if location, err := time.LoadLocation(d.conf.TimeZone); err == nil {
+			return location
+		}
+	}
+
+	return time.Local
+}
+
+// renderSVG draws the series into a SVG chart.
+//
+//nolint:cyclop,gocognit
+func renderSVG(clipID string, timeRange TimeRange, series []chartSeries, defaults FieldDefaults, style chartStyle,
+	width, height int, location *time.Location,
+) string {
+	var buf strings.Builder
+
+	plotWidth := float64(width - chartMarginLeft - chartMarginRight)
+	plotHeight := float64(height - chartMarginTop - chartMarginBottom)
+
+	fromMs, toMs := float64(timeRange.FromTime.UnixMilli()), float64(timeRange.ToTime.UnixMilli())
+	if toMs <= fromMs {
+		toMs = fromMs + 1
+	}
+
+	minValue, maxValue := seriesRange(series, defaults, style)
+	yTicks := niceTicks(minValue, maxValue, max(int(plotHeight/50), 2))
+	yMin, yMax := yTicks[0], yTicks[len(yTicks)-1]
+
+	x := func(ms int64) float64 {
+		return chartMarginLeft + (float64(ms)-fromMs)/(toMs-fromMs)*plotWidth
+	}
+
+	y := func(value float64) float64 {
+		return chartMarginTop + plotHeight - (value-yMin)/(yMax-yMin)*plotHeight
+	}
+
+	fmt.Fprintf(&buf, `<svg class="chart" xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="100%%" font-size="%d" font-family="sans-serif">`,
+		width, height, chartFontSize)
+
+	// Clip the series to the plot area
+	fmt.Fprintf(&buf, `<defs><clipPath id="%s"><rect x="%d" y="%d" width="%.1f" height="%.1f"/></clipPath></defs>`,
+		clipID, chartMarginLeft, chartMarginTop, plotWidth, plotHeight)
+
+	// Y axis with grid lines
+	for _, tick := range yTicks {
+		fmt.Fprintf(&buf, `<line x1="%d" x2="%.1f" y1="%.1f" y2="%.1f" stroke="#E0E0E0" stroke-width="0.5"/>`,
+			chartMarginLeft, chartMarginLeft+plotWidth, y(tick), y(tick))
+		fmt.Fprintf(&buf, `<text x="%d" y="%.1f" text-anchor="end" dominant-baseline="middle" fill="#555">%s</text>`,
+			chartMarginLeft-5, y(tick), html.EscapeString(FormatValue(tick, defaults.Unit, defaults.Decimals)))
+	}
+
+	// X axis with grid lines
+	for _, tick := range timeTicks(timeRange, max(int(plotWidth/100), 2), location) {
+		tickX := x(tick.UnixMilli())
+		fmt.Fprintf(&buf, `<line x1="%.1f" x2="%.1f" y1="%d" y2="%.1f" stroke="#E0E0E0" stroke-width="0.5"/>`,
+			tickX, tickX, chartMarginTop, chartMarginTop+plotHeight)
+		fmt.Fprintf(&buf, `<text x="%.1f" y="%.1f" text-anchor="middle" fill="#555">%s</text>`,
+			tickX, chartMarginTop+plotHeight+chartFontSize+5, html.EscapeString(formatTimeTick(tick, timeRange)))
+	}
+
+	fmt.Fprintf(&buf, `<g clip-path="url(#%s)">`, clipID)
+
+	// Threshold lines
+	if style.ThresholdsStyle.Mode != "" && style.ThresholdsStyle.Mode != "off" {
+		dash := ""
+		if strings.Contains(style.ThresholdsStyle.Mode, "dashed") {
+			dash = ` stroke-dasharray="6 4"`
+		}
+
+		for _, step := range defaults.Thresholds.Steps {
+			if step.Value == nil {
+				continue
+			}
+
+			fmt.Fprintf(&buf, `<line x1="%d" x2="%.1f" y1="%.1f" y2="%.1f" stroke="%s" stroke-width="1"%s/>`,
+				chartMarginLeft, chartMarginLeft+plotWidth, y(*step.Value), y(*step.Value), Color(step.Color), dash)
+		}
+	}
+
+	baseline := y(math.Max(yMin, math.Min(0, yMax)))
+
+	for idx, s := range series {
+		switch style.DrawStyle {
+		case drawStyleBars:
+			barWidth := math.Max(plotWidth/float64(max(len(s.Values), 1))*0.8/float64(len(series)), 1)
+
+			for i, value := range s.Values {
+				if math.IsNaN(value) {
+					continue
+				}
+
+				barX := x(s.Times[i]) - barWidth*float64(len(series))/2 + barWidth*float64(idx)
+				top, bottom := math.Min(y(value), baseline), math.Max(y(value), baseline)
+				fmt.Fprintf(&buf, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`,
+					barX, top, barWidth, bottom-top, s.Color)
+			}
+		case drawStylePoints:
+			for i, value := range s.Values {
+				if !math.IsNaN(value) {
+					fmt.Fprintf(&buf, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="%s"/>`, x(s.Times[i]), y(value), style.LineWidth+1, s.Color)
+				}
+			}
+		default:
+			// Lines are split into segments at null values
+			for _, segment := range splitAtNulls(s.FieldValues) {
+				points := make([]string, len(segment))
+				for i, idx := range segment {
+					points[i] = fmt.Sprintf("%.1f,%.1f", x(s.Times[idx]), y(s.Values[idx]))
+				}
+
+				if style.FillOpacity > 0 {
+					fmt.Fprintf(&buf, `<polygon points="%.1f,%.1f %s %.1f,%.1f" fill="%s" fill-opacity="%.2f" stroke="none"/>`,
+						x(s.Times[segment[0]]), baseline, strings.Join(points, " "), x(s.Times[segment[len(segment)-1]]), baseline,
+						s.Color, style.FillOpacity/100)
+				}
+
+				fmt.Fprintf(&buf, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%.1f" stroke-linejoin="round"/>`,
+					strings.Join(points, " "), s.Color, style.LineWidth)
+			}
+		}
+	}
+
+	buf.WriteString(`</g>`)
+
+	// Axis lines
+	fmt.Fprintf(&buf, `<line x1="%d" x2="%.1f" y1="%.1f" y2="%.1f" stroke="#999" stroke-width="1"/>`,
+		chartMarginLeft, chartMarginLeft+plotWidth, chartMarginTop+plotHeight, chartMarginTop+plotHeight)
+	buf.WriteString(`</svg>`)
+
+	return buf.String()
+}
+
+// splitAtNulls returns the indexes of the consecutive non-null values of the field.
+func splitAtNulls(field FieldValues) [][]int {
+	var (
+		segments [][]int
+		segment  []int
+	)
+
+	for i, value := range field.Values {
+		if math.IsNaN(value) {
+			if len(segment) > 0 {
+				segments = append(segments, segment)
+			}
+
+			segment = nil
+
+			continue
+		}
+
+		segment = append(segment, i)
+	}
+
+	if len(segment) > 0 {
+		segments = append(segments, segment)
+	}
+
+	return segments
+}
+
+// seriesRange returns the range of the y axis. Configured min and max take precedence.
+func seriesRange(series []chartSeries, defaults FieldDefaults, style chartStyle) (float64, float64) {
+	minValue, maxValue := math.Inf(1), math.Inf(-1)
+
+	for _, s := range series {
+		for _, value := range s.Values {
+			if !math.IsNaN(value) {
+				minValue, maxValue = math.Min(minValue, value), math.Max(maxValue, value)
+			}
+		}
+	}
+
+	if math.IsInf(minValue, 1) {
+		minValue, maxValue = 0, 1
+	}
+
+	// Bars and filled areas start at zero
+	if style.DrawStyle == drawStyleBars || style.FillOpacity > 0 {
+		minValue = math.Min(minValue, 0)
+	}
+
+	if defaults.Min != nil {
+		minValue = *defaults.Min
+	}
+
+	if defaults.Max != nil {
+		maxValue = *defaults.Max
+	}
+
+	return minValue, maxValue
+}
+
+// maxTicks limits the number of ticks of an axis.
+const maxTicks = 100
+
+// niceTicks returns around count evenly spaced ticks with round values covering minValue and maxValue.
+func niceTicks(minValue, maxValue float64, count int) []float64 {
+	if math.IsNaN(minValue) || math.IsNaN(maxValue) || math.IsInf(minValue, 0) || math.IsInf(maxValue, 0) {
+		minValue, maxValue = 0, 1
+	}
+
+	// Constant series and large values, whose range is lost in the precision of
+	// float64, are widened relative to their magnitude.
+	if precision := math.Abs(minValue) * 1e-9; maxValue-minValue <= precision {
+		maxValue = minValue + max(precision, 1)
+	}
+
+	rawStep := (maxValue - minValue) / float64(max(count, 1))
+	magnitude := math.Pow(10, math.Floor(math.Log10(rawStep)))
+
+	step := magnitude * 10
+
+	for _, factor := range []float64{1, 2, 5} {
+		if rawStep <= factor*magnitude {
+			step = factor * magnitude
+
+			break
+		}
+	}
+
+	if step <= 0 || math.IsNaN(step) || math.IsInf(step, 0) {
+		return []float64{minValue, maxValue}
+	}
+
+	ticks := []float64{}
+	for tick := math.Floor(minValue/step) * step; tick < maxValue+step/2 && len(ticks) < maxTicks; tick += step {
+		ticks = append(ticks, math.Round(tick/step)*step)
+
+		// The step is below the precision of the tick
+		if tick+step == tick {
+			break
+		}
+	}
+
+	if len(ticks) < 2 {
+		return []float64{minValue, maxValue}
+	}
+
+	return ticks
+}
+
+// timeTicks returns around count ticks with round times inside the time range.
+func timeTicks(timeRange TimeRange, count int, location *time.Location) []time.Time {
+	span := timeRange.ToTime.Sub(timeRange.FromTime)
+
+	step := timeSteps[len(timeSteps)-1]
+	if index := slices.IndexFunc(timeSteps, func(s time.Duration) bool { return span/s <= time.Duration(count) }); index >= 0 {
+		step = timeSteps[index]
+	}
+
+	// Align ticks to the step in the report time zone
+	from := timeRange.FromTime.In(location)
+	_, offset := from.Zone()
+	start := from.Add(time.Duration(offset) * time.Second).Truncate(step).Add(-time.Duration(offset) * time.Second)
+
+	var ticks []time.Time
+
+	for tick := start; !tick.After(timeRange.ToTime); tick = tick.Add(step) {
+		if !tick.Before(timeRange.FromTime) {
+			ticks = append(ticks, tick.In(location))
+		}
+	}
+
+	return ticks
+}
+
+// formatTimeTick formats the tick depending on the span of the time range.
+func formatTimeTick(tick time.Time, timeRange TimeRange) string {
+	span := timeRange.ToTime.Sub(timeRange.FromTime)
+
+	switch {
+	case span <= 10*time.Minute:
+		return tick.Format("15:04:05")
+	case span <= 24*time.Hour:
+		return tick.Format("15:04")
+	case span <= 7*24*time.Hour:
+		return tick.Format("01/02 15:04")
+	default:
+		return tick.Format("2006-01-02")
+	}
+}
+
+// chartConfig returns the field defaults and draw style of a chart panel. The options
+// of legacy graph panels are converted to their time series counterparts.
+//
+//nolint:cyclop
+func (p Panel) chartConfig() (FieldDefaults, chartStyle) {
+	defaults := p.FieldConfig.Defaults
+	style := chartStyle{DrawStyle: drawStyleLine, LineWidth: 1}
+
+	if len(defaults.Custom) > 0 {
+		_ = json.Unmarshal(defaults.Custom, &style)
+	}
+
+	if !p.Is(Graph) {
+		return defaults, style
+	}
+
+	var (
+		bars, points bool
+		fill         float64
+		lineWidth    float64
+		yAxes        []struct {
+			Format   string          `json:"format"`
+			Decimals *int            `json:"decimals"`
+			Min      json.RawMessage `json:"min"`
+			Max      json.RawMessage `json:"max"`
+		}
+		thresholds []struct {
+			Value     float64 `json:"value"`
+			ColorMode string  `json:"colorMode"`
+			LineColor string  `json:"lineColor"`
+		}
+	)
+
+	if json.Unmarshal(p.LegacyBars, &bars) == nil && bars {
+		style.DrawStyle = drawStyleBars
+	} else if json.Unmarshal(p.LegacyPoints, &points) == nil && points {
+		style.DrawStyle = drawStylePoints
+	}
+
+	if json.Unmarshal(p.LegacyFill, &fill) == nil {
+		style.FillOpacity = fill * 10
+	}
+
+	if json.Unmarshal(p.LegacyLineWidth, &lineWidth) == nil && lineWidth > 0 {
+		style.LineWidth = lineWidth
+	}
+
+	if json.Unmarshal(p.LegacyYAxes, &yAxes) == nil && len(yAxes) > 0 && defaults.Unit == "" {
+		defaults.Unit = yAxes[0].Format
+		defaults.Decimals = yAxes[0].Decimals
+		defaults.Min = legacyNumber(yAxes[0].Min)
+		defaults.Max = legacyNumber(yAxes[0].Max)
+	}
+
+	if json.Unmarshal(p.LegacyThresholds, &thresholds) == nil && len(thresholds) > 0 && len(defaults.Thresholds.Steps) == 0 {
+		style.ThresholdsStyle.Mode = "line"
+		defaults.Thresholds.Steps = []ThresholdStep{{}}
+
+		for _, threshold := range thresholds {
+			color := map[string]string{"critical": "red", "warning": "orange", "ok": "green"}[threshold.ColorMode]
+			if color == "" {
+				color = threshold.LineColor
+			}
+
+			defaults.Thresholds.Steps = append(defaults.Thresholds.Steps, ThresholdStep{Value: &threshold.Value, Color: color})
+		}
+	}
+
+	return defaults, style
+}
+
+// legacyNumber returns the number of an option of a legacy panel, which is stored as
+// number or as string depending on the Grafana version. Empty values return nil.
+func legacyNumber(raw json.RawMessage) *float64 {
+	var value *float64
+	if json.Unmarshal(raw, &value) == nil {
+		return value
+	}
+
+	var s string
+	if json.Unmarshal(raw, &s) != nil || s == "" {
+		return nil
+	}
+
+	number, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil
+	}
+
+	return &number
+}
+
+// showLegend returns true if the legend of the panel is visible.
+func (p Panel) showLegend() bool {
+	if p.Is(Graph) {
+		var legend struct {
+			Show *bool `json:"show"`
+		}
+
+		if json.Unmarshal(p.LegacyLegend, &legend) == nil && legend.Show != nil {
+			return *legend.Show
+		}
+
+		return true
+	}
+
+	if p.Options.Legend.ShowLegend != nil {
+		return *p.Options.Legend.ShowLegend
+	}
+
+	return p.Options.Legend.DisplayMode != "hidden"
+}
@@ -0,0 +1,151 @@
+package dashboard_test
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/dashboard"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNiceTicks(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		min, max float64
+	}{
+		{"range", 0, 100},
+		{"negative range", -42, 17},
+		{"constant", 5, 5},
+		{"constant zero", 0, 0},
+		{"near-constant", 4e15, 4e15 + 0.5},
+		{"huge constant", 4e15, 4e15},
+		{"huger constant", 1.7e18, 1.7e18},
+		{"tiny range", 0.001, 0.002},
+		{"NaN", math.NaN(), math.NaN()},
+		{"+Inf", 0, math.Inf(1)},
+		{"-Inf", math.Inf(-1), 0},
+		{"inverted", 10, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ticks := dashboard.NiceTicks(tc.min, tc.max, 5)
+
+			require.GreaterOrEqual(t, len(ticks), 2)
+			assert.LessOrEqual(t, len(ticks), 100)
+
+			for i, tick := range ticks {
+				assert.False(t, math.IsNaN(tick) || math.IsInf(tick, 0), "tick %d is %v", i, tick)
+
+				if i > 0 {
+					assert.Greater(t, tick, ticks[i-1])
+				}
+			}
+		})
+	}
+}
+
+func TestChartDefaultsLegacyGraph(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		yaxes    string
+		min, max *float64
+	}{
+		{"numbers", `[{"format":"bytes","decimals":1,"min":0,"max":100},{"format":"short"}]`, ptr(0.0), ptr(100.0)},
+		{"strings", `[{"format":"bytes","decimals":1,"min":"0","max":"100"},{"format":"short"}]`, ptr(0.0), ptr(100.0)},
+		{"unset", `[{"format":"bytes","decimals":1,"min":null,"max":""},{"format":"short"}]`, nil, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var panel dashboard.Panel
+
+			require.NoError(t, json.Unmarshal([]byte(`{
+				"id": 2,
+				"type": "graph",
+				"title": "Memory",
+				"bars": false,
+				"lines": true,
+				"linewidth": 1,
+				"fill": 1,
+				"gridPos": {"h": 8, "w": 12, "x": 0, "y": 0},
+				"yaxes": `+tc.yaxes+`
+			}`), &panel))
+
+			defaults := panel.ChartDefaults()
+
+			assert.Equal(t, "bytes", defaults.Unit)
+			require.NotNil(t, defaults.Decimals)
+			assert.Equal(t, 1, *defaults.Decimals)
+			assert.Equal(t, tc.min, defaults.Min)
+			assert.Equal(t, tc.max, defaults.Max)
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestRenderChartClipID(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/ds/query" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"results": {"A": {"frames": [{"schema": {"fields": [
+			{"name": "Time", "type": "time", "typeInfo": {"frame": "time.Time"}},
+			{"name": "Value", "type": "number", "typeInfo": {"frame": "float64"}}
+		]}, "data": {"values": [[1700000000000, 1700000060000], [1, 2]]}}]}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	dash := dashboard.New(log.NewNullLogger(), config.DefaultConfig, server.Client(), nil, nil,
+		server.URL, "uid", url.Values{}, "token")
+
+	panel := dashboard.Panel{
+		ID:         1,
+		Type:       "timeseries",
+		Datasource: &dashboard.DatasourceRef{Type: "prometheus", UID: "prom"},
+		Targets:    []map[string]any{{"refId": "A", "expr": "up"}},
+	}.WithModel()
+
+	to := time.Now()
+	current := dashboard.NewTimeRange(to.Add(-time.Hour), to)
+	previous := dashboard.NewTimeRange(to.Add(-2*time.Hour), to.Add(-time.Hour))
+
+	clipIDs := make([]string, 0, 2)
+
+	for _, timeRange := range []dashboard.TimeRange{current, previous} {
+		chart, err := dash.WithTimeRange(timeRange).RenderChart(context.Background(), dashboard.Data{TimeRange: timeRange}, panel)
+		require.NoError(t, err)
+
+		_, clipID, found := strings.Cut(string(chart.HTML), `<clipPath id="`)
+		require.True(t, found)
+
+		clipID, _, _ = strings.Cut(clipID, `"`)
+		assert.Contains(t, string(chart.HTML), `clip-path="url(#`+clipID+`)"`)
+
+		clipIDs = append(clipIDs, clipID)
+	}
+
+	assert.NotEqual(t, clipIDs[0], clipIDs[1])
+}
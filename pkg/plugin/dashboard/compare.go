@@ -0,0 +1,283 @@
+package dashboard
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Delta compares a value of the current period with the one of the previous period.
+type Delta struct {
+	Name     string
+	Current  Statistic
+	Previous Statistic
+	Change   Statistic
+	Percent  Statistic
+}
+
+// NewTimeRange returns the time range between from and to.
+func NewTimeRange(from, to time.Time) TimeRange {
+	return TimeRange{From: from.Unix(), To: to.Unix(), FromTime: from, ToTime: to}
+}
+
+// WithTimeRange returns a copy of the dashboard whose panels are rendered and queried
+// for the given absolute time range instead of the one of the request.
+func (d *Dashboard) WithTimeRange(timeRange TimeRange) *Dashboard {
+	values := maps.Clone(d.values)
+	if values == nil {
+		values = url.Values{}
+	}
+
+	values.Set("from", strconv.FormatInt(timeRange.FromTime.UnixMilli(), 10))
+	values.Set("to", strconv.FormatInt(timeRange.ToTime.UnixMilli(), 10))
+
+	return &Dashboard{
+		d.logger,
+		d.conf,
+		d.httpClient,
+		d.chromeInstance,
+		d.workerPools,
+		d.grafanaBaseURL,
+		d.uid,
+		values,
+		d.saToken,
+		d.renderer,
+		sync.Map{},
+	}
+}
+
+// CompareStat reduces the data of a stat or singlestat panel for the current and the
+// previous period using the panel's reducer and returns the change of each value.
+// Values without counterpart in the previous period have an invalid change.
+func (d *Dashboard) CompareStat(ctx context.Context, current, previous Data, panel Panel) ([]Delta, error) {
+	defaults, options := panel.statConfig()
+
+	calc := "lastNotNull"
+	if len(options.Calcs) > 0 {
+		calc = options.Calcs[0]
+	}
+
+	reduce := func(dashData Data) ([]FieldValues, map[string]float64, error) {
+		frames, err := d.QueryData(ctx, dashData, panel)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		fields := numericFields(frames, defaults, options.Fields)
+		values := make(map[string]float64, len(fields))
+
+		for _, field := range fields {
+			if value, ok := Reduce(calc, field.Values); ok {
+				values[field.Name] = value
+			}
+		}
+
+		return fields, values, nil
+	}
+
+	fields, currentValues, err := reduce(current)
+	if err != nil {
+		return nil, err
+	}
+
+	_, previousValues, err := reduce(previous)
+	if err != nil {
+		return nil, err
+	}
+
+	deltas := make([]Delta, 0, len(fields))
+
+	for _, field := range fields {
+		currentValue, currentOK := currentValues[field.Name]
+		previousValue, previousOK := previousValues[field.Name]
+
+		deltas = append(deltas, newDelta(field.Name, currentValue, currentOK, previousValue, previousOK, defaults))
+	}
+
+	return deltas, nil
+}
+
+// newDelta formats the values and their change using the unit and decimals of the field config.
+func newDelta(name string, current float64, currentOK bool, previous float64, previousOK bool, defaults FieldDefaults) Delta {
+	noValue := Statistic{Text: cmp.Or(defaults.NoValue, "-")}
+
+	statistic := func(value float64, ok bool) Statistic {
+		if !ok {
+			return noValue
+		}
+
+		return Statistic{Value: value, Text: FormatValue(value, defaults.Unit, defaults.Decimals), Valid: true}
+	}
+
+	delta := Delta{
+		Name:     name,
+		Current:  statistic(current, currentOK),
+		Previous: statistic(previous, previousOK),
+		Change:   noValue,
+		Percent:  noValue,
+	}
+
+	if !currentOK || !previousOK {
+		return delta
+	}
+
+	delta.Change = statistic(current-previous, true)
+	delta.Change.Text = signed(current-previous, delta.Change.Text)
+
+	if previous != 0 {
+		percent := (current - previous) / math.Abs(previous) * 100
+		delta.Percent = Statistic{Value: percent, Text: signed(percent, strconv.FormatFloat(percent, 'f', 1, 64)+"%"), Valid: true}
+	}
+
+	return delta
+}
+
+// signed prefixes the text of positive values with a plus sign.
+func signed(value float64, text string) string {
+	if value > 0 {
+		return "+" + text
+	}
+
+	return text
+}
+
+// CompareTable adds a delta column after each numeric column of the current table with
+// the change from the previous table. Rows are matched by their non-numeric cells, like
+// names or labels. When no row matches, for instance because the rows are time stamps,
+// rows are matched by their position instead.
+//
+//nolint:cyclop
+func CompareTable(current, previous PanelTable) PanelTable {
+	if len(current.Data) == 0 || len(previous.Data) == 0 {
+		return current
+	}
+
+	header, rows := current.Data[0], current.Data[1:]
+	previousRows := previous.Data[1:]
+
+	numeric := make([]bool, len(header))
+	for col := range header {
+		numeric[col] = len(rows) > 0 && previous.Data[0] != nil && col < len(previous.Data[0]) &&
+			previous.Data[0][col] == header[col] && isNumericColumn(rows, col)
+	}
+
+	rowKey := func(row []string) string {
+		var key strings.Builder
+
+		for col, cell := range row {
+			if col < len(numeric) && !numeric[col] {
+				key.WriteString(cell)
+				key.WriteByte(0)
+			}
+		}
+
+		return key.String()
+	}
+
+	previousByKey := make(map[string][]string, len(previousRows))
+	for _, row := range previousRows {
+		previousByKey[rowKey(row)] = row
+	}
+
+	matches := make([][]string, len(rows))
+	matched := false
+
+	for i, row := range rows {
+		if previousRow, ok := previousByKey[rowKey(row)]; ok {
+			matches[i], matched = previousRow, true
+		}
+	}
+
+	if !matched {
+		for i := range rows {
+			if i < len(previousRows) {
+				matches[i] = previousRows[i]
+			}
+		}
+	}
+
+	compared := current
+	compared.Data = make(PanelTableData, 0, len(current.Data))
+
+	compared.Data = append(compared.Data, withDeltaColumns(header, numeric, func(col int, cell string) string {
+		return "Δ " + cell
+	}))
+
+	for i, row := range rows {
+		compared.Data = append(compared.Data, withDeltaColumns(row, numeric, func(col int, cell string) string {
+			if matches[i] == nil || col >= len(matches[i]) {
+				return ""
+			}
+
+			return cellDelta(cell, matches[i][col])
+		}))
+	}
+
+	return compared
+}
+
+// withDeltaColumns returns the row with the delta cell inserted after each numeric cell.
+func withDeltaColumns(row []string, numeric []bool, delta func(col int, cell string) string) []string {
+	result := make([]string, 0, len(row)*2)
+
+	for col, cell := range row {
+		result = append(result, cell)
+
+		if col < len(numeric) && numeric[col] {
+			result = append(result, delta(col, cell))
+		}
+	}
+
+	return result
+}
+
+// isNumericColumn returns true if all non-empty cells of the column are numbers.
+func isNumericColumn(rows [][]string, col int) bool {
+	found := false
+
+	for _, row := range rows {
+		if col >= len(row) || row[col] == "" {
+			continue
+		}
+
+		if _, err := strconv.ParseFloat(row[col], 64); err != nil {
+			return false
+		}
+
+		found = true
+	}
+
+	return found
+}
+
+// cellDelta returns the change between two numeric cells using the decimals of the cells.
+func cellDelta(current, previous string) string {
+	currentValue, err := strconv.ParseFloat(current, 64)
+	if err != nil {
+		return ""
+	}
+
+	previousValue, err := strconv.ParseFloat(previous, 64)
+	if err != nil {
+		return ""
+	}
+
+	decimals := max(cellDecimals(current), cellDecimals(previous))
+
+	return signed(currentValue-previousValue, strconv.FormatFloat(currentValue-previousValue, 'f', decimals, 64))
+}
+
+// cellDecimals returns the number of decimals of a numeric cell.
+func cellDecimals(cell string) int {
+	if i := strings.IndexByte(cell, '.'); i >= 0 && !strings.ContainsAny(cell, "eE") {
+		return len(cell) - i - 1
+	}
+
+	return 0
+}
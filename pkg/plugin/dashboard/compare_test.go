@@ -0,0 +1,60 @@
+package dashboard_test
+
+import (
+	"testing"
+
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/dashboard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareTable(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		current  dashboard.PanelTableData
+		previous dashboard.PanelTableData
+		expected dashboard.PanelTableData
+	}{
+		{
+			"match rows by name",
+			dashboard.PanelTableData{{"host", "cpu", "mem"}, {"a", "1.5", "10"}, {"b", "2", "3"}, {"c", "3", "1"}},
+			dashboard.PanelTableData{{"host", "cpu", "mem"}, {"b", "1.25", "3"}, {"a", "2", "4"}},
+			dashboard.PanelTableData{
+				{"host", "cpu", "Δ cpu", "mem", "Δ mem"},
+				{"a", "1.5", "-0.5", "10", "+6"},
+				{"b", "2", "+0.75", "3", "0"},
+				{"c", "3", "", "1", ""},
+			},
+		},
+		{
+			"match rows by position",
+			dashboard.PanelTableData{{"time", "value"}, {"2024-01-08", "5"}, {"2024-01-09", "7"}},
+			dashboard.PanelTableData{{"time", "value"}, {"2024-01-01", "4"}},
+			dashboard.PanelTableData{{"time", "value", "Δ value"}, {"2024-01-08", "5", "+1"}, {"2024-01-09", "7", ""}},
+		},
+		{
+			"different columns",
+			dashboard.PanelTableData{{"host", "cpu"}, {"a", "1"}},
+			dashboard.PanelTableData{{"host", "mem"}, {"a", "2"}},
+			dashboard.PanelTableData{{"host", "cpu"}, {"a", "1"}},
+		},
+		{
+			"empty previous table",
+			dashboard.PanelTableData{{"host", "cpu"}, {"a", "1"}},
+			nil,
+			dashboard.PanelTableData{{"host", "cpu"}, {"a", "1"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := dashboard.CompareTable(
+				dashboard.PanelTable{Title: "Table", Data: tc.current},
+				dashboard.PanelTable{Title: "Table", Data: tc.previous},
+			)
+
+			assert.Equal(t, dashboard.PanelTable{Title: "Table", Data: tc.expected}, actual)
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"sync"
 
 	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/chrome"
 	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
@@ -26,25 +27,30 @@ func New(logger log.Logger, conf config.Config, httpClient *http.Client, chromeI
 		uid,
 		values,
 		saToken,
+		NewPanelRenderer(logger, conf, httpClient, chromeInstance, saToken),
+		sync.Map{},
 	}
 }
 
 func (d *Dashboard) GetData(ctx context.Context, expandRows bool) (Data, error) {
-	apiData, err := d.fetchAPI(ctx)
+	apiData, err := d.fetchAPI(ctx, expandRows)
 	if err != nil {
 		d.logger.Error("error fetching dashboard from API", "error", err)
 
 		return Data{}, fmt.Errorf("error fetching dashboard from API: %w", err)
 	}
 
-	browserData, err := d.fetchBrowser(ctx, expandRows)
+	d.resolveLibraryPanels(ctx, &apiData)
+
+	// The layout of schema v2 dashboards is known from the API already.
+	browserData, err := d.fetchBrowser(ctx, expandRows, apiData.Layout == nil)
 	if err != nil {
 		d.logger.Error("error fetching dashboard from API", "error", err)
 
 		return Data{}, fmt.Errorf("error fetching dashboard from API: %w", err)
 	}
 
-	panels, err := d.collectPanelsFromData(browserData)
+	panels, sections, err := d.collectPanelsFromData(apiData, browserData)
 	if err != nil {
 		d.logger.Error("error collecting panels from data", "error", err)
 
@@ -55,5 +61,8 @@ func (d *Dashboard) GetData(ctx context.Context, expandRows bool) (Data, error)
 		Title:     apiData.Title,
 		TimeRange: browserData.TimeRange,
 		Panels:    panels,
+		Sections:  sections,
+		Variables: d.variables(apiData),
+		Version:   apiData.Version,
 	}, err
 }
@@ -8,4 +8,13 @@ var (
 	ErrImageRendererHTTPError     = errors.New("imager renderer request does not return 200 OK")
 	ErrEmptyBlobURL               = errors.New("empty blob URL")
 	ErrEmptyCSVData               = errors.New("empty csv data")
+	ErrEmptyScreenshot            = errors.New("empty screenshot")
+	ErrUnsupportedTextMode        = errors.New("unsupported text panel mode")
+	ErrGrafanaAPIHTTPError        = errors.New("grafana API request does not return expected status")
+	ErrPanelHasNoModel            = errors.New("panel model not found in dashboard")
+	ErrPanelHasNoQueries          = errors.New("panel has no queries")
+	ErrUnsupportedDatasource      = errors.New("unsupported datasource")
+	ErrPanelQuery                 = errors.New("panel query failed")
+	ErrUnsupportedChartData       = errors.New("unsupported chart data")
+	ErrUnsupportedLayout          = errors.New("unsupported dashboard layout")
 )
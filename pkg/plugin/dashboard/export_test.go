@@ -0,0 +1,57 @@
+package dashboard
+
+import (
+	"encoding/json"
+
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
+)
+
+// NiceTicks exports niceTicks for tests.
+var NiceTicks = niceTicks
+
+// ChartDefaults returns the field defaults of the chart of the panel for tests.
+func (p Panel) ChartDefaults() FieldDefaults {
+	defaults, _ := p.chartConfig()
+
+	return defaults
+}
+
+// StatConfig returns the field defaults and reduce options of the stat panel for tests.
+func (p Panel) StatConfig() (FieldDefaults, ReduceOptions) {
+	return p.statConfig()
+}
+
+// Selected returns true if the panel is selected by the panel filters of the config.
+func Selected(conf config.Config, panel Panel) (bool, error) {
+	selector, err := newPanelSelector(conf)
+	if err != nil {
+		return false, err
+	}
+
+	return selector.Selected(panel), nil
+}
+
+// ModelMatches exports modelMatches for tests.
+var ModelMatches = modelMatches
+
+// ConvertDashboardV2 converts the schema v2 dashboard into the classic dashboard model.
+func ConvertDashboardV2(data []byte, expandRows bool) (APIDashboardData, error) {
+	var dashboard dashboardV2
+	if err := json.Unmarshal(data, &dashboard); err != nil {
+		return APIDashboardData{}, err
+	}
+
+	return dashboard.apiData(expandRows)
+}
+
+// WithModel marks the panel as enriched with its model from the dashboard API.
+func (p Panel) WithModel() Panel {
+	p.hasModel = true
+
+	return p
+}
+
+// Variables returns the current values of the template variables of the dashboard for tests.
+func (d *Dashboard) Variables(apiData APIDashboardData) Variables {
+	return d.variables(apiData)
+}
@@ -0,0 +1,381 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"html/template"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Named colors of the Grafana color palette.
+// Ref: https://github.com/grafana/grafana/blob/main/packages/grafana-data/src/themes/palette.ts
+var namedColors = map[string]string{
+	"red":                "#F2495C",
+	"semi-dark-red":      "#E02F44",
+	"dark-red":           "#C4162A",
+	"light-red":          "#FF7383",
+	"super-light-red":    "#FFA6B0",
+	"orange":             "#FF9830",
+	"semi-dark-orange":   "#FF780A",
+	"dark-orange":        "#FA6400",
+	"light-orange":       "#FFB357",
+	"super-light-orange": "#FFCB7D",
+	"yellow":             "#FADE2A",
+	"semi-dark-yellow":   "#F2CC0C",
+	"dark-yellow":        "#E0B400",
+	"light-yellow":       "#FFEE52",
+	"super-light-yellow": "#FFF899",
+	"green":              "#73BF69",
+	"semi-dark-green":    "#56A64B",
+	"dark-green":         "#37872D",
+	"light-green":        "#96D98D",
+	"super-light-green":  "#C8F2C2",
+	"blue":               "#5794F2",
+	"semi-dark-blue":     "#3274D9",
+	"dark-blue":          "#1F60C4",
+	"light-blue":         "#8AB8FF",
+	"super-light-blue":   "#C0D8FF",
+	"purple":             "#B877D9",
+	"semi-dark-purple":   "#A352CC",
+	"dark-purple":        "#8F3BB8",
+	"light-purple":       "#CA95E5",
+	"super-light-purple": "#DEB6F2",
+	"text":               "#333333",
+	"transparent":        "transparent",
+}
+
+// Regex for CSS colors which are safe to be used in a style attribute.
+var cssColorRegex = regexp.MustCompile(`^(#[0-9a-fA-F]{3,8}|rgba?\([\d\s.,%]+\)|hsla?\([\d\s.,%]+\)|[a-zA-Z]+)$`)
+
+// defaultColor is used when a field has no color.
+const defaultColor = "#333333"
+
+// Color returns the CSS color of a Grafana color name. Colors that are not
+// safe to be used in CSS are replaced by the default color.
+func Color(name string) template.CSS {
+	if color, ok := namedColors[name]; ok {
+		name = color
+	}
+
+	if !cssColorRegex.MatchString(name) {
+		name = defaultColor
+	}
+
+	return template.CSS(name) //nolint:gosec // validated above
+}
+
+// FieldValues contains the numeric values of a single field of a data frame.
+// Null values are represented as NaN.
+type FieldValues struct {
+	Name   string
+	Values []float64
+	Times  []int64
+	Config *data.FieldConfig
+}
+
+// numericFields returns the numeric fields of the frames along with their display names.
+// When filter is set, only fields whose display name matches the filter are returned. Like
+// in Grafana, filters enclosed in slashes are regular expressions.
+//
+//nolint:cyclop
+func numericFields(frames data.Frames, defaults FieldDefaults, filter string) []FieldValues {
+	var fields []FieldValues
+
+	filterRegex := compileFieldFilter(filter)
+
+	numericCount := 0
+
+	for _, frame := range frames {
+		for _, field := range frame.Fields {
+			if field.Type().Numeric() {
+				numericCount++
+			}
+		}
+	}
+
+	for _, frame := range frames {
+		var timeField *data.Field
+
+		for _, field := range frame.Fields {
+			if field.Type().Time() {
+				timeField = field
+
+				break
+			}
+		}
+
+		for _, field := range frame.Fields {
+			if !field.Type().Numeric() {
+				continue
+			}
+
+			name := displayName(frame, field, defaults, numericCount)
+
+			if filter != "" && filterRegex == nil && name != filter && field.Name != filter ||
+				filterRegex != nil && !filterRegex.MatchString(name) {
+				continue
+			}
+
+			values := FieldValues{
+				Name:   name,
+				Values: make([]float64, field.Len()),
+				Config: field.Config,
+			}
+
+			if timeField != nil {
+				values.Times = make([]int64, field.Len())
+			}
+
+			for i := range field.Len() {
+				values.Values[i] = math.NaN()
+
+				if value, err := field.NullableFloatAt(i); err == nil && value != nil {
+					values.Values[i] = *value
+				}
+
+				if timeField != nil && i < timeField.Len() {
+					if t, ok := timeField.ConcreteAt(i); ok {
+						if tt, ok := timeAt(t); ok {
+							values.Times[i] = tt
+						}
+					}
+				}
+			}
+
+			fields = append(fields, values)
+		}
+	}
+
+	return fields
+}
+
+// compileFieldFilter returns the regular expression of a field filter like /cpu.*/, if any.
+func compileFieldFilter(filter string) *regexp.Regexp {
+	if len(filter) < 2 || !strings.HasPrefix(filter, "/") || !strings.HasSuffix(filter, "/") {
+		return nil
+	}
+
+	re, err := regexp.Compile(filter[1 : len(filter)-1])
+	if err != nil {
+		return nil
+	}
+
+	return re
+}
+
+// timeAt returns the unix milliseconds of a time field value.
+func timeAt(value any) (int64, bool) {
+	switch t := value.(type) {
+	case interface{ UnixMilli() int64 }:
+		return t.UnixMilli(), true
+	default:
+		return 0, false
+	}
+}
+
+// displayName returns the display name of a field similar to Grafana.
+func displayName(frame *data.Frame, field *data.Field, defaults FieldDefaults, numericCount int) string {
+	switch {
+	case defaults.DisplayName != "" && !strings.Contains(defaults.DisplayName, "${"):
+		return defaults.DisplayName
+	case field.Config != nil && field.Config.DisplayNameFromDS != "":
+		return field.Config.DisplayNameFromDS
+	case field.Config != nil && field.Config.DisplayName != "":
+		return field.Config.DisplayName
+	case len(field.Labels) == 1:
+		for _, value := range field.Labels {
+			return value
+		}
+	case len(field.Labels) > 1:
+		keys := make([]string, 0, len(field.Labels))
+		for key := range field.Labels {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		labels := make([]string, len(keys))
+		for i, key := range keys {
+			labels[i] = key + "=" + strconv.Quote(field.Labels[key])
+		}
+
+		return "{" + strings.Join(labels, ", ") + "}"
+	case frame.Name != "" && numericCount > 1:
+		return frame.Name
+	}
+
+	return field.Name
+}
+
+// Reduce reduces the values into a single value using the Grafana calculation.
+// Null values are represented as NaN. Returns false, if there is no value.
+//
+//nolint:cyclop
+func Reduce(calc string, values []float64) (float64, bool) {
+	notNull := slices.DeleteFunc(slices.Clone(values), math.IsNaN)
+
+	switch calc {
+	case "last":
+		if len(values) == 0 || math.IsNaN(values[len(values)-1]) {
+			return 0, false
+		}
+
+		return values[len(values)-1], true
+	case "first":
+		if len(values) == 0 || math.IsNaN(values[0]) {
+			return 0, false
+		}
+
+		return values[0], true
+	case "count":
+		return float64(len(values)), true
+	}
+
+	if len(notNull) == 0 {
+		return 0, false
+	}
+
+	switch calc {
+	case "firstNotNull":
+		return notNull[0], true
+	case "min":
+		return slices.Min(notNull), true
+	case "max":
+		return slices.Max(notNull), true
+	case "sum":
+		return sum(notNull), true
+	case "mean":
+		return sum(notNull) / float64(len(notNull)), true
+	case "median":
+		return Percentile(notNull, 50), true
+	case "range":
+		return slices.Max(notNull) - slices.Min(notNull), true
+	case "diff":
+		return notNull[len(notNull)-1] - notNull[0], true
+	case "delta":
+		// Delta is the total increase of a counter, ignoring counter resets.
+		var delta float64
+
+		for i := 1; i < len(notNull); i++ {
+			if notNull[i] >= notNull[i-1] {
+				delta += notNull[i] - notNull[i-1]
+			} else {
+				delta += notNull[i]
+			}
+		}
+
+		return delta, true
+	default: // lastNotNull
+		return notNull[len(notNull)-1], true
+	}
+}
+
+// Percentile returns the p-th percentile of the values using linear interpolation.
+func Percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+func sum(values []float64) float64 {
+	var total float64
+
+	for _, value := range values {
+		total += value
+	}
+
+	return total
+}
+
+// ThresholdColor returns the color of the threshold step matching the value.
+// In percentage mode, the value is compared relative to minValue and maxValue.
+func (t Thresholds) ThresholdColor(value, minValue, maxValue float64) string {
+	if len(t.Steps) == 0 {
+		return ""
+	}
+
+	if t.Mode == "percentage" && maxValue > minValue {
+		value = (value - minValue) / (maxValue - minValue) * 100
+	}
+
+	// The first step is the base and always matches.
+	color := t.Steps[0].Color
+
+	for _, step := range t.Steps[1:] {
+		if step.Value != nil && value >= *step.Value {
+			color = step.Color
+		}
+	}
+
+	return color
+}
+
+// valueMappingResult is the text and color of a value mapping.
+type valueMappingResult struct {
+	Text  string `json:"text"`
+	Color string `json:"color"`
+}
+
+// MapValue applies the value mappings of the field to the value.
+// Returns false, if no mapping matches.
+//
+//nolint:cyclop
+func (f FieldDefaults) MapValue(value float64, isNull bool) (string, string, bool) {
+	for _, mapping := range f.Mappings {
+		switch mapping.Type {
+		case "value":
+			var options map[string]valueMappingResult
+			if err := json.Unmarshal(mapping.Options, &options); err != nil || isNull {
+				continue
+			}
+
+			if result, ok := options[strconv.FormatFloat(value, 'f', -1, 64)]; ok {
+				return result.Text, result.Color, true
+			}
+		case "range":
+			var options struct {
+				From   *float64           `json:"from"`
+				To     *float64           `json:"to"`
+				Result valueMappingResult `json:"result"`
+			}
+			if err := json.Unmarshal(mapping.Options, &options); err != nil || isNull {
+				continue
+			}
+
+			if (options.From == nil || value >= *options.From) && (options.To == nil || value <= *options.To) {
+				return options.Result.Text, options.Result.Color, true
+			}
+		case "special":
+			var options struct {
+				Match  string             `json:"match"`
+				Result valueMappingResult `json:"result"`
+			}
+			if err := json.Unmarshal(mapping.Options, &options); err != nil {
+				continue
+			}
+
+			if isNull && (options.Match == "null" || options.Match == "null+nan") ||
+				!isNull && options.Match == "true" && value != 0 ||
+				!isNull && options.Match == "false" && value == 0 {
+				return options.Result.Text, options.Result.Color, true
+			}
+		}
+	}
+
+	return "", "", false
+}
@@ -0,0 +1,129 @@
+package dashboard_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/dashboard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReduce(t *testing.T) {
+	t.Parallel()
+
+	nan := math.NaN()
+	values := []float64{nan, 4, 2, nan, 8, 6, nan}
+
+	for _, tc := range []struct {
+		calc     string
+		values   []float64
+		expected float64
+		ok       bool
+	}{
+		{"lastNotNull", values, 6, true},
+		{"", values, 6, true},
+		{"last", values, 0, false},
+		{"last", []float64{1, 2}, 2, true},
+		{"first", values, 0, false},
+		{"first", []float64{1, 2}, 1, true},
+		{"firstNotNull", values, 4, true},
+		{"min", values, 2, true},
+		{"max", values, 8, true},
+		{"sum", values, 20, true},
+		{"mean", values, 5, true},
+		{"median", values, 5, true},
+		{"range", values, 6, true},
+		{"diff", values, 2, true},
+		{"delta", []float64{1, 3, 2, 5}, 7, true},
+		{"count", values, 7, true},
+		{"count", nil, 0, true},
+		{"lastNotNull", []float64{nan}, 0, false},
+		{"mean", nil, 0, false},
+	} {
+		t.Run(tc.calc, func(t *testing.T) {
+			t.Parallel()
+
+			actual, ok := dashboard.Reduce(tc.calc, tc.values)
+
+			assert.Equal(t, tc.ok, ok)
+			assert.InDelta(t, tc.expected, actual, 1e-9)
+		})
+	}
+}
+
+func TestThresholdColor(t *testing.T) {
+	t.Parallel()
+
+	// The base step has no value in the dashboard model and stands for -Infinity.
+	steps := []dashboard.ThresholdStep{{Color: "green"}, {Value: ptr(50.0), Color: "orange"}, {Value: ptr(80.0), Color: "red"}}
+
+	for _, tc := range []struct {
+		name       string
+		thresholds dashboard.Thresholds
+		value      float64
+		expected   string
+	}{
+		{"no steps", dashboard.Thresholds{}, 10, ""},
+		{"base", dashboard.Thresholds{Steps: steps}, 10, "green"},
+		{"below all steps", dashboard.Thresholds{Steps: steps}, math.Inf(-1), "green"},
+		{"on step", dashboard.Thresholds{Steps: steps}, 50, "orange"},
+		{"between steps", dashboard.Thresholds{Steps: steps}, 79.9, "orange"},
+		{"above last step", dashboard.Thresholds{Steps: steps}, 1000, "red"},
+		{"base only", dashboard.Thresholds{Steps: steps[:1]}, 1000, "green"},
+		{"percentage", dashboard.Thresholds{Mode: "percentage", Steps: steps}, 170, "orange"},
+		{"percentage above", dashboard.Thresholds{Mode: "percentage", Steps: steps}, 190, "red"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// The percentage cases use a range of 100 to 200.
+			assert.Equal(t, tc.expected, tc.thresholds.ThresholdColor(tc.value, 100, 200))
+		})
+	}
+}
+
+func TestMapValue(t *testing.T) {
+	t.Parallel()
+
+	mapping := func(mappingType, options string) dashboard.ValueMapping {
+		return dashboard.ValueMapping{Type: mappingType, Options: json.RawMessage(options)}
+	}
+
+	defaults := dashboard.FieldDefaults{Mappings: []dashboard.ValueMapping{
+		mapping("value", `{"1": {"text": "Up", "color": "green"}, "0.5": {"text": "Half"}}`),
+		mapping("range", `{"from": 10, "to": 20, "result": {"text": "Low", "color": "yellow"}}`),
+		mapping("range", `{"from": 90, "result": {"text": "High", "color": "red"}}`),
+		mapping("special", `{"match": "null", "result": {"text": "Missing", "color": "gray"}}`),
+		mapping("special", `{"match": "false", "result": {"text": "Down", "color": "red"}}`),
+		mapping("value", `invalid`),
+	}}
+
+	for _, tc := range []struct {
+		name   string
+		value  float64
+		isNull bool
+		text   string
+		color  string
+		mapped bool
+	}{
+		{"value", 1, false, "Up", "green", true},
+		{"value without color", 0.5, false, "Half", "", true},
+		{"range", 15, false, "Low", "yellow", true},
+		{"range bounds", 20, false, "Low", "yellow", true},
+		{"open range", 1000, false, "High", "red", true},
+		{"special null", math.NaN(), true, "Missing", "gray", true},
+		{"special false", 0, false, "Down", "red", true},
+		{"not mapped", 42, false, "", "", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			text, color, mapped := defaults.MapValue(tc.value, tc.isNull)
+
+			assert.Equal(t, tc.text, text)
+			assert.Equal(t, tc.color, color)
+			assert.Equal(t, tc.mapped, mapped)
+		})
+	}
+}
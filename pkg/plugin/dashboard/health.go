@@ -0,0 +1,120 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// PanelStatus is the status of the data of a panel.
+type PanelStatus string
+
+const (
+	// PanelStatusOK means the queries of the panel returned data.
+	PanelStatusOK PanelStatus = "ok"
+	// PanelStatusUnknown means the data of the panel cannot be checked, for
+	// instance for text panels or unsupported data sources.
+	PanelStatusUnknown PanelStatus = "unknown"
+	// PanelStatusError means a query of the panel failed.
+	PanelStatusError PanelStatus = "error"
+	// PanelStatusNoData means the queries of the panel returned no data.
+	PanelStatusNoData PanelStatus = "nodata"
+	// PanelStatusUnavailable means the check itself failed, for instance because
+	// the query API of Grafana could not be reached. The panel is rendered as usual.
+	PanelStatusUnavailable PanelStatus = "unavailable"
+)
+
+// PanelHealth is the result of checking the data of a panel.
+type PanelHealth struct {
+	Panel   Panel
+	Status  PanelStatus
+	Message string
+}
+
+// Healthy returns false, if the query of the panel failed or returned no data.
+// Panels whose check failed are considered healthy, as transient failures of the
+// check must not affect the report.
+func (h PanelHealth) Healthy() bool {
+	return h.Status != PanelStatusError && h.Status != PanelStatusNoData
+}
+
+// CheckPanel checks whether the queries of the panel succeed and return data.
+// Panels whose data cannot be queried through the API have an unknown status.
+func (d *Dashboard) CheckPanel(ctx context.Context, dashData Data, panel Panel) PanelHealth {
+	health := PanelHealth{Panel: panel, Status: PanelStatusOK}
+
+	frames, err := d.QueryData(ctx, dashData, panel)
+
+	switch {
+	case errors.Is(err, ErrPanelHasNoModel), errors.Is(err, ErrPanelHasNoQueries), errors.Is(err, ErrUnsupportedDatasource):
+		health.Status = PanelStatusUnknown
+	case errors.Is(err, ErrPanelQuery):
+		health.Status = PanelStatusError
+		health.Message = err.Error()
+	case err != nil:
+		health.Status = PanelStatusUnavailable
+		health.Message = err.Error()
+
+		d.logger.Warn("failed to check panel", "panel_id", panel.ID, "err", err)
+	case !hasData(frames):
+		health.Status = PanelStatusNoData
+		health.Message = "No data"
+	}
+
+	if !health.Healthy() {
+		d.logger.Debug("panel is unhealthy", "panel_id", panel.ID, "status", health.Status, "message", health.Message)
+	}
+
+	return health
+}
+
+// hasData returns true if any field of the frames has values.
+func hasData(frames data.Frames) bool {
+	return slices.ContainsFunc(frames, func(frame *data.Frame) bool {
+		return slices.ContainsFunc(frame.Fields, func(field *data.Field) bool { return field.Len() > 0 })
+	})
+}
+
+// RemovePanels returns a copy of the data without the panels for which remove returns
+// true. Sections without panels are removed as well.
+func (d Data) RemovePanels(remove func(index int, panel Panel) bool) Data {
+	indexes := make([]int, len(d.Panels))
+	panels := make([]Panel, 0, len(d.Panels))
+
+	for i, panel := range d.Panels {
+		indexes[i] = -1
+
+		if !remove(i, panel) {
+			indexes[i] = len(panels)
+			panels = append(panels, panel)
+		}
+	}
+
+	sections := make([]Section, 0, len(d.Sections))
+
+	for _, section := range d.Sections {
+		panelIndexes := make([]int, 0, len(section.PanelIndexes))
+
+		for _, i := range section.PanelIndexes {
+			if indexes[i] >= 0 {
+				panelIndexes = append(panelIndexes, indexes[i])
+			}
+		}
+
+		if len(panelIndexes) > 0 {
+			section.PanelIndexes = panelIndexes
+			sections = append(sections, section)
+		}
+	}
+
+	// The first section always starts on the first page.
+	if len(sections) > 0 {
+		sections[0].PageBreak = false
+	}
+
+	d.Panels, d.Sections = panels, sections
+
+	return d
+}
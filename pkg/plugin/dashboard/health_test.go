@@ -0,0 +1,152 @@
+package dashboard_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/dashboard"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemovePanels(t *testing.T) {
+	t.Parallel()
+
+	data := dashboard.Data{
+		Panels: []dashboard.Panel{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}},
+		Sections: []dashboard.Section{
+			{PanelIndexes: []int{0}},
+			{Title: "Row 1", PanelIndexes: []int{1, 2}, PageBreak: true},
+			{Title: "Row 2", PanelIndexes: []int{3}, PageBreak: true},
+		},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		remove   []int
+		expected dashboard.Data
+	}{
+		{
+			"remove none",
+			nil,
+			data,
+		},
+		{
+			"remove panel of section",
+			[]int{2},
+			dashboard.Data{
+				Panels: []dashboard.Panel{{ID: 1}, {ID: 3}, {ID: 4}},
+				Sections: []dashboard.Section{
+					{PanelIndexes: []int{0}},
+					{Title: "Row 1", PanelIndexes: []int{1}, PageBreak: true},
+					{Title: "Row 2", PanelIndexes: []int{2}, PageBreak: true},
+				},
+			},
+		},
+		{
+			"remove empty sections",
+			[]int{1, 2, 3},
+			dashboard.Data{
+				Panels:   []dashboard.Panel{{ID: 4}},
+				Sections: []dashboard.Section{{Title: "Row 2", PanelIndexes: []int{0}}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := data.RemovePanels(func(_ int, panel dashboard.Panel) bool {
+				for _, id := range tc.remove {
+					if panel.ID == id {
+						return true
+					}
+				}
+
+				return false
+			})
+
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestCheckPanel(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/frontend/settings":
+			_, _ = w.Write([]byte(`{"defaultDatasource": "Prometheus", "datasources": {"Prometheus": {"type": "prometheus", "uid": "prom"}}}`))
+		case "/api/ds/query":
+			var body struct {
+				Queries []struct {
+					Datasource dashboard.DatasourceRef `json:"datasource"`
+					Expr       string                  `json:"expr"`
+				} `json:"queries"`
+			}
+
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "prom", body.Queries[0].Datasource.UID)
+
+			switch body.Queries[0].Expr {
+			case "up":
+				_, _ = w.Write([]byte(`{"results": {"A": {"frames": [{"schema": {"fields": [{"name": "Value", "type": "number", "typeInfo": {"frame": "float64"}}]}, "data": {"values": [[1]]}}]}}}`))
+			case "empty":
+				_, _ = w.Write([]byte(`{"results": {"A": {"frames": []}}}`))
+			case "invalid":
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"results": {"A": {"error": "parse error", "status": 400}}}`))
+			default:
+				w.WriteHeader(http.StatusBadGateway)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	prom := &dashboard.DatasourceRef{Type: "prometheus", UID: "prom"}
+
+	for _, tc := range []struct {
+		name       string
+		datasource *dashboard.DatasourceRef
+		expr       string
+		expected   dashboard.PanelStatus
+		healthy    bool
+	}{
+		{"data", prom, "up", dashboard.PanelStatusOK, true},
+		{"default datasource", nil, "up", dashboard.PanelStatusOK, true},
+		{"no data", prom, "empty", dashboard.PanelStatusNoData, false},
+		{"query error", prom, "invalid", dashboard.PanelStatusError, false},
+		{"API failure", prom, "unavailable", dashboard.PanelStatusUnavailable, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dash := dashboard.New(log.NewNullLogger(), config.DefaultConfig, server.Client(), nil, nil,
+				server.URL, "uid", url.Values{}, "token")
+
+			panel := dashboard.Panel{
+				ID:         1,
+				Type:       "timeseries",
+				Datasource: tc.datasource,
+				Targets:    []map[string]any{{"refId": "A", "expr": tc.expr}},
+			}.WithModel()
+
+			to := time.Now()
+			from := to.Add(-time.Hour)
+			timeRange := dashboard.TimeRange{From: from.UnixMilli(), To: to.UnixMilli(), FromTime: from, ToTime: to}
+
+			health := dash.CheckPanel(context.Background(), dashboard.Data{TimeRange: timeRange}, panel)
+
+			assert.Equal(t, tc.expected, health.Status, health.Message)
+			assert.Equal(t, tc.healthy, health.Healthy())
+		})
+	}
+}
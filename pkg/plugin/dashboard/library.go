@@ -0,0 +1,101 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// LibraryPanel references the library panel a dashboard panel is linked to.
+type LibraryPanel struct {
+	UID        string `json:"uid"`
+	Name       string `json:"name"`
+	FolderName string `json:"-"`
+}
+
+// libraryElement is the response of the library elements API.
+type libraryElement struct {
+	Result struct {
+		UID   string          `json:"uid"`
+		Name  string          `json:"name"`
+		Model json.RawMessage `json:"model"`
+		Meta  struct {
+			FolderName string `json:"folderName"`
+		} `json:"meta"`
+	} `json:"result"`
+}
+
+// IsLibraryPanel returns true if the panel is linked to a library panel.
+func (p Panel) IsLibraryPanel() bool {
+	return p.LibraryPanel != nil
+}
+
+// resolveLibraryPanels replaces the library panel references of the dashboard model,
+// including those inside collapsed rows, by the models of the library panels. Library
+// panels that cannot be fetched are kept as references, as the browser still knows
+// their type and title.
+func (d *Dashboard) resolveLibraryPanels(ctx context.Context, apiData *APIDashboardData) {
+	models := make(map[string]Panel)
+
+	resolve := func(panel *Panel) {
+		if !panel.IsLibraryPanel() {
+			return
+		}
+
+		model, ok := models[panel.LibraryPanel.UID]
+		if !ok {
+			var err error
+
+			model, err = d.fetchLibraryPanel(ctx, panel.LibraryPanel.UID)
+			if err != nil {
+				d.logger.Warn("error fetching library panel", "panel_id", panel.ID, "uid", panel.LibraryPanel.UID, "err", err)
+
+				return
+			}
+
+			models[panel.LibraryPanel.UID] = model
+		}
+
+		// Only the position and ID of the panel are specific to the dashboard.
+		id, gridPos := panel.ID, panel.GridPos
+		*panel = model
+		panel.ID, panel.GridPos = id, gridPos
+	}
+
+	for i := range apiData.RowOrPanels {
+		resolve(&apiData.RowOrPanels[i].Panel)
+
+		for j := range apiData.RowOrPanels[i].Panels {
+			resolve(&apiData.RowOrPanels[i].Panels[j])
+		}
+	}
+}
+
+// fetchLibraryPanel fetches the model of a library panel using the library elements API.
+func (d *Dashboard) fetchLibraryPanel(ctx context.Context, uid string) (Panel, error) {
+	apiURL, err := d.apiURL("api/library-elements", uid)
+	if err != nil {
+		return Panel{}, err
+	}
+
+	var element libraryElement
+
+	if err := d.doJSON(ctx, http.MethodGet, apiURL, nil, &element); err != nil {
+		return Panel{}, err
+	}
+
+	var panel Panel
+
+	if err := json.Unmarshal(element.Result.Model, &panel); err != nil {
+		return Panel{}, fmt.Errorf("error decoding model of library panel %s: %w", uid, err)
+	}
+
+	panel.LibraryPanel = &LibraryPanel{
+		UID:        element.Result.UID,
+		Name:       element.Result.Name,
+		FolderName: element.Result.Meta.FolderName,
+	}
+
+	return panel, nil
+}
@@ -1,12 +1,15 @@
 package dashboard
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
 	"regexp"
 	"slices"
 	"strconv"
 	"strings"
+
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
 )
 
 // Regex for parsing X and Y co-ordinates from CSS
@@ -18,21 +21,164 @@ const (
 	scaleHeight = 36
 )
 
+// excludeMarker excludes a panel from the report when found in its description or links.
+const excludeMarker = "report:exclude"
+
+// panelSelector decides which panels are part of the report based on the
+// include and exclude filters of the config.
+type panelSelector struct {
+	includeIDs, excludeIDs       []int
+	includeTypes, excludeTypes   []string
+	includeTitles, excludeTitles []*regexp.Regexp
+	includeRows, excludeRows     []*regexp.Regexp
+}
+
+// newPanelSelector compiles the panel filters of the config.
+func newPanelSelector(conf config.Config) (panelSelector, error) {
+	selector := panelSelector{
+		includeIDs:   conf.IncludePanelIDs,
+		excludeIDs:   conf.ExcludePanelIDs,
+		includeTypes: conf.IncludePanelTypes,
+		excludeTypes: conf.ExcludePanelTypes,
+	}
+
+	for _, filter := range []struct {
+		patterns []string
+		target   *[]*regexp.Regexp
+	}{
+		{conf.IncludePanelTitles, &selector.includeTitles},
+		{conf.ExcludePanelTitles, &selector.excludeTitles},
+		{conf.IncludeRowTitles, &selector.includeRows},
+		{conf.ExcludeRowTitles, &selector.excludeRows},
+	} {
+		for _, pattern := range filter.patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return panelSelector{}, fmt.Errorf("invalid panel filter %q: %w", pattern, err)
+			}
+
+			*filter.target = append(*filter.target, re)
+		}
+	}
+
+	return selector, nil
+}
+
+// hasInclude returns true if any include filter is configured.
+func (s panelSelector) hasInclude() bool {
+	return len(s.includeIDs) > 0 || len(s.includeTypes) > 0 || len(s.includeTitles) > 0 || len(s.includeRows) > 0
+}
+
+// Selected returns true if the panel is part of the report. When include filters
+// are set, only panels matching at least one of them are selected. Panels included
+// by ID are always selected, so that the ID filters keep their precedence. For the
+// other filters, exclude filters and the exclude marker take precedence.
+func (s panelSelector) Selected(panel Panel) bool {
+	if slices.Contains(s.includeIDs, panel.ID) {
+		return true
+	}
+
+	return (!s.hasInclude() || s.included(panel)) && !s.excluded(panel)
+}
+
+func (s panelSelector) included(panel Panel) bool {
+	return slices.Contains(s.includeTypes, panel.Type) ||
+		matchAny(s.includeTitles, panel.Title) ||
+		panel.RowTitle != "" && matchAny(s.includeRows, panel.RowTitle)
+}
+
+func (s panelSelector) excluded(panel Panel) bool {
+	return slices.Contains(s.excludeIDs, panel.ID) ||
+		slices.Contains(s.excludeTypes, panel.Type) ||
+		matchAny(s.excludeTitles, panel.Title) ||
+		panel.RowTitle != "" && matchAny(s.excludeRows, panel.RowTitle) ||
+		panel.hasExcludeMarker()
+}
+
+// hasExcludeMarker returns true if the description or a link of the panel contains the exclude marker.
+func (p Panel) hasExcludeMarker() bool {
+	if strings.Contains(p.Description, excludeMarker) {
+		return true
+	}
+
+	return slices.ContainsFunc(p.Links, func(link PanelLink) bool {
+		return strings.Contains(link.Title, excludeMarker) || strings.Contains(link.URL, excludeMarker)
+	})
+}
+
+func matchAny(regexes []*regexp.Regexp, s string) bool {
+	return slices.ContainsFunc(regexes, func(re *regexp.Regexp) bool { return re.MatchString(s) })
+}
+
 //nolint:cyclop
-func (d *Dashboard) collectPanelsFromData(browserData BrowserData) ([]Panel, error) {
-	panels := make([]Panel, 0, len(browserData.PanelData))
+func (d *Dashboard) collectPanelsFromData(apiData APIDashboardData, browserData BrowserData) ([]Panel, []Section, error) {
+	models := panelModels(apiData)
+
+	selector, err := newPanelSelector(d.conf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The layout of schema v2 dashboards is known from the API, the layout of
+	// classic dashboards is taken from the browser to include repeated panels.
+	layout := apiData.Layout
+	if layout == nil {
+		if layout, err = browserLayout(browserData); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	panels := make([]Panel, 0, len(layout))
+
+	for _, item := range layout {
+		// Start from the panel model, if known. Repeated panels are not part of the model.
+		panel, ok := models[item.ID]
+		panel.hasModel = ok && modelMatches(panel, item)
+
+		panel.ID = item.ID
+		panel.Title = cmp.Or(item.Title, panel.Title)
+		panel.Type = cmp.Or(item.Type, panel.Type)
+		panel.GridPos = item.GridPos
+
+		panels = append(panels, panel)
+	}
+
+	rows := slices.DeleteFunc(slices.Clone(panels), func(panel Panel) bool { return !panel.IsRow() })
+	slices.SortFunc(rows, func(a, b Panel) int { return cmp.Compare(a.GridPos.Y, b.GridPos.Y) })
+
+	for i, panel := range panels {
+		if row := rowIndex(rows, panel); row >= 0 {
+			panels[i].RowTitle = rows[row].Title
+		}
+	}
+
+	panels = slices.DeleteFunc(panels, func(panel Panel) bool {
+		if panel.IsRow() {
+			return true
+		}
+
+		if !selector.Selected(panel) {
+			d.logger.Debug("panel excluded from report", "panel_id", panel.ID, "title", panel.Title)
+
+			return true
+		}
+
+		return false
+	})
+
+	return panels, d.sections(rows, panels), nil
+}
 
+// browserLayout returns the panels and rows of the dashboard as found in the browser
+// with their ID, title, type and grid position.
+func browserLayout(browserData BrowserData) ([]Panel, error) {
 	if browserData.PanelData == nil {
 		return nil, errors.New("apiData.RowOrPanels or browserData.PanelData is nil")
 	}
 
-	for _, browserPanel := range browserData.PanelData {
-		if len(d.conf.IncludePanelIDs) > 0 && slices.Contains(d.conf.IncludePanelIDs, browserPanel.ID) ||
-			len(d.conf.ExcludePanelIDs) > 0 && !slices.Contains(d.conf.ExcludePanelIDs, browserPanel.ID) ||
-			browserPanel.Type == "row" {
-			continue
-		}
+	layout := make([]Panel, 0, len(browserData.PanelData))
 
+	for _, browserPanel := range browserData.PanelData {
 		panelWidth, err := strconv.Atoi(strings.TrimSuffix(browserPanel.Width, "px"))
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert width to int for panel ID %d: %w", browserPanel.ID, err)
@@ -58,7 +204,7 @@ func (d *Dashboard) collectPanelsFromData(browserData BrowserData) ([]Panel, err
 			return nil, fmt.Errorf("failed to convert Y co-ordinate to int for panel ID %d: %w", browserPanel.ID, err)
 		}
 
-		panels = append(panels, Panel{
+		layout = append(layout, Panel{
 			ID:    browserPanel.ID,
 			Title: browserPanel.Title,
 			Type:  browserPanel.Type,
@@ -71,5 +217,118 @@ func (d *Dashboard) collectPanelsFromData(browserData BrowserData) ([]Panel, err
 		})
 	}
 
-	return panels, nil
+	return layout, nil
+}
+
+// modelLayout returns the panels and rows of the dashboard model with their grid positions.
+// Panels of collapsed rows are only included when expandRows is true. Like in Grafana,
+// expanding a row moves the panels below it down.
+func modelLayout(apiData APIDashboardData, expandRows bool) []Panel {
+	var (
+		layout []Panel
+		offset float64
+	)
+
+	for _, rowOrPanel := range apiData.RowOrPanels {
+		item := Panel{ID: rowOrPanel.ID, Title: rowOrPanel.Title, Type: rowOrPanel.Type, GridPos: rowOrPanel.GridPos}
+		item.GridPos.Y += offset
+
+		layout = append(layout, item)
+
+		if !rowOrPanel.Collapsed || !expandRows || len(rowOrPanel.Panels) == 0 {
+			continue
+		}
+
+		top := slices.MinFunc(rowOrPanel.Panels, func(a, b Panel) int { return cmp.Compare(a.GridPos.Y, b.GridPos.Y) }).GridPos.Y
+		bottom := top
+
+		for _, panel := range rowOrPanel.Panels {
+			gridPos := panel.GridPos
+			gridPos.Y += item.GridPos.Y + 1 - top
+
+			layout = append(layout, Panel{ID: panel.ID, Title: panel.Title, Type: panel.Type, GridPos: gridPos})
+			bottom = max(bottom, panel.GridPos.Y+panel.GridPos.H)
+		}
+
+		offset += bottom - top
+	}
+
+	return layout
+}
+
+// rowIndex returns the index of the row containing the panel, which is the closest
+// row above it. Returns -1, if the panel is above the first row.
+func rowIndex(rows []Panel, panel Panel) int {
+	index := -1
+
+	for i, row := range rows {
+		if row.ID == panel.ID || row.GridPos.Y > panel.GridPos.Y {
+			break
+		}
+
+		index = i
+	}
+
+	return index
+}
+
+// sections groups the panels by the rows containing them. Panels above the first
+// row form a section without title. Rows without panels are omitted.
+func (d *Dashboard) sections(rows []Panel, panels []Panel) []Section {
+	sections := make([]Section, len(rows)+1)
+
+	for i, row := range rows {
+		// Rows are always one grid unit high.
+		sections[i+1] = Section{Title: row.Title, Y: row.GridPos.Y + 1}
+	}
+
+	for i, panel := range panels {
+		section := &sections[rowIndex(rows, panel)+1]
+		section.PanelIndexes = append(section.PanelIndexes, i)
+	}
+
+	sections = slices.DeleteFunc(sections, func(section Section) bool { return len(section.PanelIndexes) == 0 })
+
+	// The first section always starts on the first page.
+	for i := 1; i < len(sections); i++ {
+		sections[i].PageBreak = d.conf.RowsOnNewPage
+	}
+
+	return sections
+}
+
+// panelModels returns the panels of the dashboard model keyed by their ID.
+// Panels inside collapsed rows are included as well.
+func panelModels(apiData APIDashboardData) map[int]Panel {
+	models := make(map[int]Panel, len(apiData.RowOrPanels))
+
+	for _, rowOrPanel := range apiData.RowOrPanels {
+		models[rowOrPanel.ID] = rowOrPanel.Panel
+
+		for _, panel := range rowOrPanel.Panels {
+			models[panel.ID] = panel
+		}
+	}
+
+	return models
+}
+
+// migratedPanelTypes maps legacy panel types to the types the frontend migrates
+// them to when the dashboard is loaded. The native renderers understand the
+// options of both.
+var migratedPanelTypes = map[string]string{
+	SingleStat.String(): Stat.String(),
+	Graph.String():      TimeSeries.String(),
+}
+
+// modelMatches returns true if the panel model belongs to the panel of the
+// layout. The IDs of repeated panels may collide with the ID of another panel
+// of the model, in which case the types differ. Layouts of schema v2 dashboards
+// do not carry a type and always match.
+func modelMatches(model, item Panel) bool {
+	if item.IsRow() {
+		return false
+	}
+
+	return item.Type == "" || item.Type == model.Type || migratedPanelTypes[model.Type] == item.Type
 }
@@ -0,0 +1,115 @@
+package dashboard_test
+
+import (
+	"testing"
+
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/dashboard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelected(t *testing.T) {
+	t.Parallel()
+
+	cpu := dashboard.Panel{ID: 1, Type: "timeseries", Title: "CPU usage", RowTitle: "Nodes"}
+	marked := dashboard.Panel{ID: 2, Type: "stat", Title: "CPU count", Description: "Internal, report:exclude"}
+	linked := dashboard.Panel{ID: 3, Type: "stat", Title: "Memory", Links: []dashboard.PanelLink{{Title: "Docs", URL: "https://example.com/?report:exclude"}}}
+	debug := dashboard.Panel{ID: 4, Type: "text", Title: "Notes", RowTitle: "Debug"}
+	top := dashboard.Panel{ID: 5, Type: "text", Title: "Notes"}
+
+	for _, tc := range []struct {
+		name     string
+		conf     func(conf *config.Config)
+		panel    dashboard.Panel
+		expected bool
+	}{
+		{"no filters", func(*config.Config) {}, cpu, true},
+		{"no filters with marker", func(*config.Config) {}, marked, false},
+		{"no filters with marker in link", func(*config.Config) {}, linked, false},
+		{"included by ID", func(conf *config.Config) { conf.IncludePanelIDs = []int{1} }, cpu, true},
+		{"not included by ID", func(conf *config.Config) { conf.IncludePanelIDs = []int{9} }, cpu, false},
+		{"excluded by ID", func(conf *config.Config) { conf.ExcludePanelIDs = []int{1} }, cpu, false},
+		{"included and excluded by ID", func(conf *config.Config) {
+			conf.IncludePanelIDs = []int{1}
+			conf.ExcludePanelIDs = []int{1}
+		}, cpu, true},
+		{"included by ID and excluded by type", func(conf *config.Config) {
+			conf.IncludePanelIDs = []int{1}
+			conf.ExcludePanelTypes = []string{"timeseries"}
+		}, cpu, true},
+		{"included by title and excluded by ID", func(conf *config.Config) {
+			conf.IncludePanelTitles = []string{"^CPU"}
+			conf.ExcludePanelIDs = []int{1}
+		}, cpu, false},
+		{"included by title and excluded by type", func(conf *config.Config) {
+			conf.IncludePanelTitles = []string{"^CPU"}
+			conf.ExcludePanelTypes = []string{"timeseries"}
+		}, cpu, false},
+		{"included by title and not excluded by type", func(conf *config.Config) {
+			conf.IncludePanelTitles = []string{"^CPU"}
+			conf.ExcludePanelTypes = []string{"text"}
+		}, cpu, true},
+		{"included by ID with marker", func(conf *config.Config) { conf.IncludePanelIDs = []int{2} }, marked, true},
+		{"included by title with marker", func(conf *config.Config) { conf.IncludePanelTitles = []string{"^CPU"} }, marked, false},
+		{"included by type with marker in link", func(conf *config.Config) { conf.IncludePanelTypes = []string{"stat"} }, linked, false},
+		{"included by row", func(conf *config.Config) { conf.IncludeRowTitles = []string{"^Nodes$"} }, cpu, true},
+		{"not included by row", func(conf *config.Config) { conf.IncludeRowTitles = []string{"^Nodes$"} }, debug, false},
+		{"excluded by row", func(conf *config.Config) { conf.ExcludeRowTitles = []string{"Debug"} }, debug, false},
+		{"included by row and excluded by title", func(conf *config.Config) {
+			conf.IncludeRowTitles = []string{"Nodes"}
+			conf.ExcludePanelTitles = []string{"usage$"}
+		}, cpu, false},
+		{"included by type and excluded by row", func(conf *config.Config) {
+			conf.IncludePanelTypes = []string{"text"}
+			conf.ExcludeRowTitles = []string{"Debug"}
+		}, debug, false},
+		{"panel without row not excluded by row", func(conf *config.Config) { conf.ExcludeRowTitles = []string{".*"} }, top, true},
+		{"panel without row not included by row", func(conf *config.Config) { conf.IncludeRowTitles = []string{".*"} }, top, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			conf := config.Config{}
+			tc.conf(&conf)
+
+			selected, err := dashboard.Selected(conf, tc.panel)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, selected)
+		})
+	}
+}
+
+func TestSelectedWithInvalidFilter(t *testing.T) {
+	t.Parallel()
+
+	_, err := dashboard.Selected(config.Config{IncludePanelTitles: []string{"(unclosed"}}, dashboard.Panel{})
+	require.ErrorContains(t, err, "invalid panel filter")
+}
+
+func TestModelMatches(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name      string
+		modelType string
+		itemType  string
+		expected  bool
+	}{
+		{"same type", "stat", "stat", true},
+		{"schema v2 layout without type", "stat", "", true},
+		{"singlestat migrated to stat", "singlestat", "stat", true},
+		{"graph migrated to timeseries", "graph", "timeseries", true},
+		{"repeated panel with colliding ID", "table", "stat", false},
+		{"stat not migrated back", "stat", "singlestat", false},
+		{"row", "row", "row", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			model := dashboard.Panel{ID: 1, Type: tc.modelType}
+			item := dashboard.Panel{ID: 1, Type: tc.itemType}
+			assert.Equal(t, tc.expected, dashboard.ModelMatches(model, item))
+		})
+	}
+}
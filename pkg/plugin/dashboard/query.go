@@ -0,0 +1,299 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Special data source UIDs of Grafana.
+const (
+	datasourceMixed     = "-- Mixed --"
+	datasourceDashboard = "-- Dashboard --"
+)
+
+// defaultMaxDataPoints is used when the panel does not set max data points.
+// It corresponds roughly to the width of a full width panel in pixels.
+const defaultMaxDataPoints = 1000
+
+// queryKey identifies the query results of a panel in a time range.
+type queryKey struct {
+	panelID  int
+	from, to int64
+}
+
+// defaultDatasourceKey identifies the default data source of the organization in the query cache.
+type defaultDatasourceKey struct{}
+
+// queryResult holds the query results of a panel.
+type queryResult struct {
+	frames data.Frames
+	err    error
+}
+
+// QueryData executes the queries of the panel using the Grafana data source query API
+// and returns the resulting data frames. Results are kept for the lifetime of the
+// dashboard, so that checking and rendering a panel queries the data sources once.
+func (d *Dashboard) QueryData(ctx context.Context, dashData Data, panel Panel) (data.Frames, error) {
+	key := queryKey{panel.ID, dashData.TimeRange.FromTime.UnixMilli(), dashData.TimeRange.ToTime.UnixMilli()}
+
+	if result, ok := d.queries.Load(key); ok {
+		return result.(queryResult).frames, result.(queryResult).err //nolint:forcetypeassert
+	}
+
+	frames, err := d.queryData(ctx, dashData, panel)
+
+	// Do not keep the results of cancelled requests
+	if ctx.Err() == nil {
+		d.queries.Store(key, queryResult{frames, err})
+	}
+
+	return frames, err
+}
+
+// queryData executes the queries of the panel.
+//
+//nolint:cyclop
+func (d *Dashboard) queryData(ctx context.Context, dashData Data, panel Panel) (data.Frames, error) {
+	if !panel.HasModel() {
+		return nil, ErrPanelHasNoModel
+	}
+
+	maxDataPoints := panel.MaxDataPoints
+	if maxDataPoints <= 0 {
+		maxDataPoints = defaultMaxDataPoints
+	}
+
+	intervalMs := dashData.TimeRange.ToTime.Sub(dashData.TimeRange.FromTime).Milliseconds() / int64(maxDataPoints)
+
+	if minInterval, ok := parseInterval(dashData.Interpolate(panel.Interval)); ok && minInterval.Milliseconds() > intervalMs {
+		intervalMs = minInterval.Milliseconds()
+	}
+
+	intervalMs = max(intervalMs, 1)
+
+	queries := make([]map[string]any, 0, len(panel.Targets))
+	refIDs := make([]string, 0, len(panel.Targets))
+
+	for idx, target := range panel.Targets {
+		if hide, _ := target["hide"].(bool); hide {
+			continue
+		}
+
+		datasourceRef := panel.Datasource
+		if targetRef := targetDatasource(target); targetRef != nil && (datasourceRef == nil || datasourceRef.UID == datasourceMixed) {
+			datasourceRef = targetRef
+		}
+
+		datasource, err := d.resolveDatasource(ctx, dashData, datasourceRef)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving datasource of panel %d: %w", panel.ID, err)
+		}
+
+		query, _ := interpolateQuery(dashData, target).(map[string]any)
+		query["datasource"] = datasource
+		query["intervalMs"] = intervalMs
+		query["maxDataPoints"] = maxDataPoints
+
+		refID, _ := query["refId"].(string)
+		if refID == "" {
+			refID = string(rune('A' + idx))
+			query["refId"] = refID
+		}
+
+		queries = append(queries, query)
+		refIDs = append(refIDs, refID)
+	}
+
+	if len(queries) == 0 {
+		return nil, ErrPanelHasNoQueries
+	}
+
+	apiURL, err := d.apiURL("api/ds/query")
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]any{
+		"queries": queries,
+		"from":    strconv.FormatInt(dashData.TimeRange.FromTime.UnixMilli(), 10),
+		"to":      strconv.FormatInt(dashData.TimeRange.ToTime.UnixMilli(), 10),
+	}
+
+	var resp backend.QueryDataResponse
+
+	// Grafana returns 207 Multi-Status and 400 Bad Request along with the query
+	// results when some of the queries fail.
+	if err := d.doJSON(ctx, http.MethodPost, apiURL, body, &resp, http.StatusOK, http.StatusMultiStatus, http.StatusBadRequest); err != nil {
+		return nil, fmt.Errorf("error querying data of panel %d: %w", panel.ID, err)
+	}
+
+	var (
+		frames data.Frames
+		errs   []error
+	)
+
+	for _, refID := range refIDs {
+		result, ok := resp.Responses[refID]
+		if !ok {
+			continue
+		}
+
+		if result.Error != nil {
+			errs = append(errs, fmt.Errorf("%w: query %s: %w", ErrPanelQuery, refID, result.Error))
+		}
+
+		frames = append(frames, result.Frames...)
+	}
+
+	if len(errs) > 0 {
+		return frames, errors.Join(errs...)
+	}
+
+	return frames, nil
+}
+
+// resolveDatasource returns the data source reference to be used in queries.
+// Data sources referenced by name are resolved to their UID using the Grafana API.
+// Panels without data source use the default data source of the organization.
+func (d *Dashboard) resolveDatasource(ctx context.Context, dashData Data, ref *DatasourceRef) (DatasourceRef, error) {
+	if ref == nil || ref.Name == "default" || *ref == (DatasourceRef{}) {
+		return d.defaultDatasource(ctx)
+	}
+
+	if ref.Name != "" {
+		apiURL, err := d.apiURL("api/datasources/name", dashData.Interpolate(ref.Name))
+		if err != nil {
+			return DatasourceRef{}, err
+		}
+
+		var resolved DatasourceRef
+		if err := d.doJSON(ctx, http.MethodGet, apiURL, nil, &resolved); err != nil {
+			return DatasourceRef{}, fmt.Errorf("error fetching datasource %s: %w", ref.Name, err)
+		}
+
+		return resolved, nil
+	}
+
+	resolved := DatasourceRef{
+		Type: ref.Type,
+		UID:  dashData.Interpolate(ref.UID),
+	}
+
+	if resolved.UID == "" || resolved.UID == datasourceDashboard || resolved.UID == datasourceMixed {
+		return DatasourceRef{}, fmt.Errorf("%w: %q", ErrUnsupportedDatasource, resolved.UID)
+	}
+
+	return resolved, nil
+}
+
+// defaultDatasource returns the default data source of the organization as found in the
+// frontend settings of Grafana, which are readable by all users.
+func (d *Dashboard) defaultDatasource(ctx context.Context) (DatasourceRef, error) {
+	if ref, ok := d.queries.Load(defaultDatasourceKey{}); ok {
+		return ref.(DatasourceRef), nil //nolint:forcetypeassert
+	}
+
+	apiURL, err := d.apiURL("api/frontend/settings")
+	if err != nil {
+		return DatasourceRef{}, err
+	}
+
+	var settings struct {
+		DefaultDatasource string                   `json:"defaultDatasource"`
+		Datasources       map[string]DatasourceRef `json:"datasources"`
+	}
+
+	if err := d.doJSON(ctx, http.MethodGet, apiURL, nil, &settings); err != nil {
+		return DatasourceRef{}, fmt.Errorf("error fetching default datasource: %w", err)
+	}
+
+	ref, ok := settings.Datasources[settings.DefaultDatasource]
+	if !ok || ref.UID == "" {
+		return DatasourceRef{}, fmt.Errorf("%w: default datasource %q not found", ErrUnsupportedDatasource, settings.DefaultDatasource)
+	}
+
+	d.queries.Store(defaultDatasourceKey{}, ref)
+
+	return ref, nil
+}
+
+// targetDatasource returns the data source reference of a query target, if any.
+func targetDatasource(target map[string]any) *DatasourceRef {
+	raw, ok := target["datasource"]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+
+	var ref DatasourceRef
+	if err := json.Unmarshal(data, &ref); err != nil {
+		return nil
+	}
+
+	return &ref
+}
+
+// interpolateQuery returns a copy of the query target with template variables replaced
+// in all string values. Multi-value variables are formatted as regex, which is what
+// most of the data sources expect.
+func interpolateQuery(dashData Data, value any) any {
+	switch v := value.(type) {
+	case string:
+		return dashData.interpolate(v, "regex")
+	case map[string]any:
+		query := make(map[string]any, len(v))
+		for key, item := range v {
+			query[key] = interpolateQuery(dashData, item)
+		}
+
+		return query
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = interpolateQuery(dashData, item)
+		}
+
+		return items
+	default:
+		return v
+	}
+}
+
+// parseInterval parses Grafana intervals like 30s, >1m or 1d.
+func parseInterval(interval string) (time.Duration, bool) {
+	interval = strings.TrimPrefix(strings.TrimSpace(interval), ">")
+	if interval == "" {
+		return 0, false
+	}
+
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour, "y": 365 * 24 * time.Hour} {
+		if value, ok := strings.CutSuffix(interval, suffix); ok {
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return 0, false
+			}
+
+			return time.Duration(n * float64(unit)), true
+		}
+	}
+
+	duration, err := time.ParseDuration(interval)
+	if err != nil {
+		return 0, false
+	}
+
+	return duration, true
+}
@@ -11,15 +11,85 @@ import (
 	"net/url"
 	"strconv"
 	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/chrome"
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// Names of the panel renderers in the config.
+const (
+	ImageRenderer   = "image-renderer"
+	BrowserRenderer = "browser"
 )
 
+// PanelRenderer renders panels as PNG images.
+type PanelRenderer interface {
+	// Name returns the name of the renderer in the config.
+	Name() string
+	// RenderPNG renders the panel of the d-solo page of the request.
+	RenderPNG(ctx context.Context, request RenderRequest) (PanelImage, error)
+}
+
+// RenderRequest is the d-solo page of a panel along with the size of its image.
+type RenderRequest struct {
+	// BaseURL is the URL of Grafana.
+	BaseURL *url.URL
+	// DashboardUID is the UID of the dashboard of the panel.
+	DashboardUID string
+	// Path is the path of the d-solo page relative to BaseURL.
+	Path  string
+	Query url.Values
+
+	Width  int
+	Height int
+	// Scale is the device scale factor, which multiplies the pixels of the image.
+	Scale float64
+}
+
+// URL returns the URL of the d-solo page under the prefix, e.g., render for the image renderer.
+func (r RenderRequest) URL(prefix string) string {
+	u := r.BaseURL.JoinPath(prefix, r.Path)
+	u.RawQuery = r.Query.Encode()
+
+	return u.String()
+}
+
+// NewPanelRenderer returns the renderer of the config. If fallback is enabled in the
+// config, the other renderer is used when the configured one fails.
+func NewPanelRenderer(logger log.Logger, conf config.Config, httpClient *http.Client,
+	chromeInstance chrome.Instance, saToken string,
+) PanelRenderer {
+	imageRenderer := &imageRenderer{
+		logger:     logger,
+		httpClient: httpClient,
+		saToken:    saToken,
+		retry:      conf.RetryPolicy(),
+		timeout:    time.Duration(conf.RenderTimeout),
+	}
+	browserRenderer := &browserRenderer{logger: logger, conf: conf, chromeInstance: chromeInstance, saToken: saToken}
+
+	var primary, secondary PanelRenderer = imageRenderer, browserRenderer
+	if conf.PanelRenderer == BrowserRenderer {
+		primary, secondary = browserRenderer, imageRenderer
+	}
+
+	if !conf.PanelRendererFallback {
+		return primary
+	}
+
+	return &fallbackRenderer{logger: logger, primary: primary, fallback: secondary}
+}
+
 func (d *Dashboard) FetchPNG(ctx context.Context, panel Panel) (PanelImage, error) {
-	panelPNGURL, err := d.getPanelPNGURL(panel)
+	request, err := d.renderRequest(panel)
 	if err != nil {
 		return PanelImage{}, fmt.Errorf("error getting panel PNG URL: %w", err)
 	}
 
-	panelImage, err := d.fetchPNGFromGrafanaAPI(ctx, panelPNGURL)
+	panelImage, err := d.renderer.RenderPNG(ctx, request)
 	if err != nil {
 		return PanelImage{}, fmt.Errorf("error fetching panel PNG: %w", err)
 	}
@@ -29,17 +99,22 @@ func (d *Dashboard) FetchPNG(ctx context.Context, panel Panel) (PanelImage, erro
 	return panelImage, nil
 }
 
-func (d *Dashboard) getPanelPNGURL(panel Panel) (string, error) {
-	dashURL, err := url.Parse(d.grafanaBaseURL)
+func (d *Dashboard) renderRequest(panel Panel) (RenderRequest, error) {
+	baseURL, err := url.Parse(d.grafanaBaseURL)
 	if err != nil {
-		return "", fmt.Errorf("error parsing Grafana base URL: %w", err)
+		return RenderRequest{}, fmt.Errorf("error parsing Grafana base URL: %w", err)
 	}
 
-	dashURL = dashURL.JoinPath("render/d-solo", d.uid, "_")
+	request := RenderRequest{
+		BaseURL:      baseURL,
+		DashboardUID: d.uid,
+		Path:         "d-solo/" + d.uid + "/_",
+		Query:        maps.Clone(d.values),
+		Scale:        d.conf.PanelScaleFactor,
+	}
 
-	dashURLValues := maps.Clone(d.values)
-	dashURLValues.Set("theme", d.conf.Theme)
-	dashURLValues.Set("panelId", strconv.Itoa(panel.ID))
+	request.Query.Set("theme", d.conf.Theme)
+	request.Query.Set("panelId", strconv.Itoa(panel.ID))
 
 	// If using a grid layout we use 100px for width and 36px for height scaling.
 	// Grafana panels are fitted into 24 units width and height units are said to
@@ -48,71 +123,112 @@ func (d *Dashboard) getPanelPNGURL(panel Panel) (string, error) {
 	// In simple layout we create panels with 1000x500 resolution always and include
 	// them one in each page of report
 	if d.conf.Layout == "grid" {
-		width := int(panel.GridPos.W * 100)
-		height := int(panel.GridPos.H * 36)
-
-		dashURLValues.Set("width", strconv.Itoa(width))
-		dashURLValues.Set("height", strconv.Itoa(height))
+		request.Width = int(panel.GridPos.W * 100)
+		request.Height = int(panel.GridPos.H * 36)
 	} else {
-		dashURLValues.Set("width", "1000")
-		dashURLValues.Set("height", "500")
+		request.Width = 1000
+		request.Height = 500
 	}
 
-	dashURL.RawQuery = dashURLValues.Encode()
+	request.Query.Set("width", strconv.Itoa(request.Width))
+	request.Query.Set("height", strconv.Itoa(request.Height))
+
+	if request.Scale != 1 {
+		request.Query.Set("scale", strconv.FormatFloat(request.Scale, 'f', -1, 64))
+	}
 
-	// Get Panel API endpoint
-	return dashURL.String(), nil
+	return request, nil
 }
 
-func (d *Dashboard) fetchPNGFromGrafanaAPI(ctx context.Context, panelURL string) (PanelImage, error) {
-	// Create a new request for panel
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, panelURL, nil)
-	if err != nil {
-		return PanelImage{}, fmt.Errorf("error creating request for %s: %w", panelURL, err)
+// imageRenderer renders panels with the grafana-image-renderer plugin or service.
+// Failed renders are retried according to the retry policy.
+type imageRenderer struct {
+	logger     log.Logger
+	httpClient *http.Client
+	saToken    string
+	retry      config.RetryPolicy
+	timeout    time.Duration
+}
+
+func (r *imageRenderer) Name() string {
+	return ImageRenderer
+}
+
+func (r *imageRenderer) RenderPNG(ctx context.Context, request RenderRequest) (PanelImage, error) {
+	panelURL := request.URL("render")
+
+	for attempt := 1; ; attempt++ {
+		panelImage, retryAfter, err := r.fetch(ctx, panelURL)
+		if err == nil {
+			return panelImage, nil
+		}
+
+		if retryAfter < 0 || attempt >= r.retry.MaxAttempts || ctx.Err() != nil {
+			return PanelImage{}, err
+		}
+
+		backoff := max(r.retry.Backoff(attempt), min(retryAfter, r.retry.MaxBackoff))
+
+		r.logger.Debug("retrying panel PNG", "url", panelURL, "attempt", attempt, "backoff", backoff, "err", err)
+
+		select {
+		case <-ctx.Done():
+			return PanelImage{}, fmt.Errorf("error waiting to retry %s: %w", panelURL, ctx.Err())
+		case <-time.After(backoff):
+		}
 	}
+}
 
-	// Add the Authorization header
-	req.Header.Add("Authorization", "Bearer "+d.saToken)
+// fetch fetches the panel PNG once. If the request can be retried, it returns the delay
+// requested by the Retry-After header or 0. Otherwise, it returns -1.
+func (r *imageRenderer) fetch(ctx context.Context, panelURL string) (PanelImage, time.Duration, error) {
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
 
-	d.logger.Debug("fetching panel PNG", "url", panelURL)
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
 
-	// Send the request
-	resp, err := d.httpClient.Do(req) //nolint:bodyclose //https://github.com/timakin/bodyclose/issues/30
+	// Create a new request for panel
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, panelURL, nil)
 	if err != nil {
-		return PanelImage{}, fmt.Errorf("error sending request: %w", err)
+		return PanelImage{}, -1, fmt.Errorf("error creating request for %s: %w", panelURL, err)
 	}
 
-	// Do multiple tries to get panel before giving up
-	for retries := 1; retries < 3 && resp.StatusCode != http.StatusOK; retries++ {
-		if err = resp.Body.Close(); err != nil {
-			return PanelImage{}, fmt.Errorf("error closing response body: %w", err)
-		}
+	// Add the Authorization header
+	req.Header.Add("Authorization", "Bearer "+r.saToken)
 
-		time.Sleep(10 * time.Second * time.Duration(retries))
+	r.logger.Debug("fetching panel PNG", "url", panelURL)
 
-		resp, err = d.httpClient.Do(req) //nolint:bodyclose //https://github.com/timakin/bodyclose/issues/30
-		if err != nil {
-			return PanelImage{}, fmt.Errorf("error executing retry request for %s: %w", panelURL, err)
-		}
+	// Send the request, which is retried if it failed or timed out
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return PanelImage{}, 0, fmt.Errorf("error sending request for %s: %w", panelURL, err)
 	}
 
 	// Close the response body
 	defer func(body io.ReadCloser) {
 		if err := body.Close(); err != nil {
-			d.logger.Error("error closing response body", "error", err)
+			r.logger.Error("error closing response body", "error", err)
 		}
 	}(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 
-		return PanelImage{}, fmt.Errorf(
+		err = fmt.Errorf(
 			"%w: URL: %s. Status: %s, message: %s",
 			ErrImageRendererHTTPError,
 			panelURL,
 			resp.Status,
 			string(body),
 		)
+
+		if !r.retry.Retryable(resp.StatusCode) {
+			return PanelImage{}, -1, err
+		}
+
+		return PanelImage{}, retryAfter(resp.Header), err
 	}
 
 	sb := &bytes.Buffer{}
@@ -121,11 +237,127 @@ func (d *Dashboard) fetchPNGFromGrafanaAPI(ctx context.Context, panelURL string)
 	encoder := base64.NewEncoder(base64.StdEncoding, sb)
 
 	if _, err = io.Copy(encoder, resp.Body); err != nil {
-		return PanelImage{}, fmt.Errorf("error reading response body of panel PNG: %w", err)
+		return PanelImage{}, 0, fmt.Errorf("error reading response body of panel PNG: %w", err)
+	}
+
+	if err = encoder.Close(); err != nil {
+		return PanelImage{}, -1, fmt.Errorf("error encoding panel PNG: %w", err)
 	}
 
 	return PanelImage{
 		Image:    sb.String(),
 		MimeType: "image/png",
+	}, 0, nil
+}
+
+// retryAfter returns the delay of the Retry-After header in seconds or 0.
+func retryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+// browserRenderer renders panels by taking a screenshot of the d-solo page in a tab
+// of the browser of the plugin. It does not need the grafana-image-renderer.
+type browserRenderer struct {
+	logger         log.Logger
+	conf           config.Config
+	chromeInstance chrome.Instance
+	saToken        string
+}
+
+func (r *browserRenderer) Name() string {
+	return BrowserRenderer
+}
+
+func (r *browserRenderer) RenderPNG(ctx context.Context, request RenderRequest) (PanelImage, error) {
+	var buf []byte
+
+	err := chrome.RetryOnCrash(ctx, r.chromeInstance, r.logger, func() error {
+		var err error
+
+		buf, err = r.screenshot(ctx, request)
+
+		return err
+	})
+	if err != nil {
+		return PanelImage{}, err //nolint:wrapcheck
+	}
+
+	return PanelImage{
+		Image:    base64.StdEncoding.EncodeToString(buf),
+		MimeType: "image/png",
 	}, nil
 }
+
+// screenshot loads the d-solo page with a viewport of the size of the panel and takes
+// a screenshot once the panel has finished loading.
+func (r *browserRenderer) screenshot(ctx context.Context, request RenderRequest) (_ []byte, err error) {
+	tab := r.chromeInstance.NewTab(ctx, r.logger, r.conf)
+	tab.WithTimeout(time.Duration(r.conf.RenderTimeout))
+
+	defer tab.Close(r.logger)
+
+	// Attach the console messages, exceptions and failed requests of the page to errors
+	defer func() { err = tab.AttachDiagnostics(ctx, err) }()
+
+	panelURL := request.URL("")
+
+	// Set the OAuth token in the headers
+	headers := map[string]any{backend.OAuthIdentityTokenHeaderName: "Bearer " + r.saToken}
+
+	r.logger.Debug("rendering panel PNG via browser", "url", panelURL)
+
+	viewport := chromedp.EmulateViewport(int64(request.Width), int64(request.Height), chromedp.EmulateScale(request.Scale))
+	if err := tab.Run(viewport); err != nil {
+		return nil, fmt.Errorf("error setting viewport: %w", err)
+	}
+
+	if err := tab.NavigateAndWaitReady(panelURL, headers, r.conf.Readiness(request.DashboardUID)); err != nil {
+		return nil, fmt.Errorf("error waiting for panel %s to load: %w", panelURL, err)
+	}
+
+	var buf []byte
+
+	if err := tab.Run(chromedp.CaptureScreenshot(&buf)); err != nil {
+		return nil, fmt.Errorf("error taking screenshot of panel %s: %w", panelURL, err)
+	}
+
+	if len(buf) == 0 {
+		return nil, fmt.Errorf("error taking screenshot of panel %s: %w", panelURL, ErrEmptyScreenshot)
+	}
+
+	return buf, nil
+}
+
+// fallbackRenderer renders panels with the fallback renderer, if the primary renderer
+// fails, e.g., because the grafana-image-renderer is not installed.
+type fallbackRenderer struct {
+	logger   log.Logger
+	primary  PanelRenderer
+	fallback PanelRenderer
+}
+
+func (r *fallbackRenderer) Name() string {
+	return r.primary.Name()
+}
+
+func (r *fallbackRenderer) RenderPNG(ctx context.Context, request RenderRequest) (PanelImage, error) {
+	panelImage, err := r.primary.RenderPNG(ctx, request)
+	if err == nil || ctx.Err() != nil {
+		return panelImage, err
+	}
+
+	r.logger.Warn("panel renderer failed, falling back",
+		"renderer", r.primary.Name(), "fallback", r.fallback.Name(), "err", err)
+
+	panelImage, fallbackErr := r.fallback.RenderPNG(ctx, request)
+	if fallbackErr != nil {
+		return PanelImage{}, fmt.Errorf("%s: %w; %s: %w", r.primary.Name(), err, r.fallback.Name(), fallbackErr)
+	}
+
+	return panelImage, nil
+}
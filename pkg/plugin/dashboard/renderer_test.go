@@ -0,0 +1,111 @@
+package dashboard_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/dashboard"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderRequestURL(t *testing.T) {
+	t.Parallel()
+
+	baseURL, err := url.Parse("http://grafana:3000/grafana/")
+	require.NoError(t, err)
+
+	request := dashboard.RenderRequest{
+		BaseURL: baseURL,
+		Path:    "d-solo/uid/_",
+		Query:   url.Values{"panelId": []string{"2"}},
+	}
+
+	assert.Equal(t, "http://grafana:3000/grafana/render/d-solo/uid/_?panelId=2", request.URL("render"))
+	assert.Equal(t, "http://grafana:3000/grafana/d-solo/uid/_?panelId=2", request.URL(""))
+}
+
+func TestImageRenderer(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/render/d-solo/uid/_", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		_, _ = w.Write([]byte("png"))
+	}))
+	defer server.Close()
+
+	baseURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	conf := config.Config{PanelRenderer: dashboard.ImageRenderer}
+	renderer := dashboard.NewPanelRenderer(log.NewNullLogger(), conf, server.Client(), nil, "token")
+
+	assert.Equal(t, dashboard.ImageRenderer, renderer.Name())
+
+	image, err := renderer.RenderPNG(context.Background(), dashboard.RenderRequest{
+		BaseURL: baseURL,
+		Path:    "d-solo/uid/_",
+		Query:   url.Values{},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("png")), image.Image)
+	assert.Equal(t, "image/png", image.MimeType)
+}
+
+func TestImageRendererRetries(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		statuses []int
+		calls    int
+		err      bool
+	}{
+		{"retryable status is retried", []int{http.StatusServiceUnavailable, http.StatusOK}, 2, false},
+		{"attempts are limited", []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}, 3, true},
+		{"other status is not retried", []int{http.StatusNotFound}, 1, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tc.statuses[calls.Add(1)-1])
+			}))
+			defer server.Close()
+
+			baseURL, err := url.Parse(server.URL)
+			require.NoError(t, err)
+
+			conf := config.Config{
+				PanelRenderer:       dashboard.ImageRenderer,
+				RenderTimeout:       config.Duration(time.Second),
+				RetryMaxAttempts:    3,
+				RetryInitialBackoff: config.Duration(time.Millisecond),
+				RetryMaxBackoff:     config.Duration(time.Millisecond),
+				RetryStatusCodes:    []int{http.StatusBadGateway, http.StatusServiceUnavailable},
+			}
+			renderer := dashboard.NewPanelRenderer(log.NewNullLogger(), conf, server.Client(), nil, "token")
+
+			_, err = renderer.RenderPNG(context.Background(), dashboard.RenderRequest{
+				BaseURL: baseURL,
+				Path:    "d-solo/uid/_",
+				Query:   url.Values{},
+			})
+
+			assert.Equal(t, tc.err, err != nil)
+			assert.Equal(t, int32(tc.calls), calls.Load())
+		})
+	}
+}
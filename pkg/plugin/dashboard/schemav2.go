@@ -0,0 +1,445 @@
+package dashboard
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// API versions of schema v2 dashboards, newest first.
+var dashboardV2APIVersions = []string{"v2beta1", "v2alpha1"}
+
+// Heights of auto grid rows in grid units by row height mode.
+var autoGridRowHeights = map[string]float64{
+	"short":    5,
+	"standard": 9,
+	"tall":     14,
+}
+
+// Layout kinds of schema v2 dashboards.
+const (
+	layoutGrid     = "GridLayout"
+	layoutRows     = "RowsLayout"
+	layoutTabs     = "TabsLayout"
+	layoutAutoGrid = "AutoGridLayout"
+
+	gridLayoutRow = "GridLayoutRow"
+)
+
+// dashboardV2 is a dashboard of schema v2 as returned by the dashboard.grafana.app API.
+// Ref: https://grafana.com/docs/grafana/latest/as-code/observability-as-code/schema-v2/
+type dashboardV2 struct {
+	Spec struct {
+		Title       string               `json:"title"`
+		Description string               `json:"description"`
+		Elements    map[string]elementV2 `json:"elements"`
+		Layout      layoutV2             `json:"layout"`
+		Variables   []struct {
+			Spec Variable `json:"spec"`
+		} `json:"variables"`
+	} `json:"spec"`
+}
+
+// elementV2 is a panel or library panel element of a schema v2 dashboard.
+type elementV2 struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		ID           int           `json:"id"`
+		Title        string        `json:"title"`
+		Description  string        `json:"description"`
+		Links        []PanelLink   `json:"links"`
+		LibraryPanel *LibraryPanel `json:"libraryPanel"`
+		Data         struct {
+			Spec struct {
+				Queries []struct {
+					Spec queryV2 `json:"spec"`
+				} `json:"queries"`
+				QueryOptions struct {
+					Interval      string `json:"interval"`
+					MaxDataPoints int    `json:"maxDataPoints"`
+				} `json:"queryOptions"`
+			} `json:"spec"`
+		} `json:"data"`
+		VizConfig struct {
+			Kind  string `json:"kind"`  // v2alpha1
+			Group string `json:"group"` // v2beta1
+			Spec  struct {
+				Options     PanelOptions `json:"options"`
+				FieldConfig FieldConfig  `json:"fieldConfig"`
+			} `json:"spec"`
+		} `json:"vizConfig"`
+	} `json:"spec"`
+}
+
+// queryV2 is a panel query of a schema v2 dashboard.
+type queryV2 struct {
+	RefID      string         `json:"refId"`
+	Hidden     bool           `json:"hidden"`
+	Datasource *DatasourceRef `json:"datasource"` // v2alpha1
+	Query      struct {
+		Kind       string `json:"kind"`  // v2alpha1: type of the data source
+		Group      string `json:"group"` // v2beta1: type of the data source
+		Datasource struct {
+			Name string `json:"name"` // v2beta1: UID of the data source
+		} `json:"datasource"`
+		Spec map[string]any `json:"spec"`
+	} `json:"query"`
+}
+
+// layoutV2 is a layout of a schema v2 dashboard. The spec depends on the kind of layout.
+type layoutV2 struct {
+	Kind string          `json:"kind"`
+	Spec json.RawMessage `json:"spec"`
+}
+
+type gridLayoutItemV2 struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		X         float64         `json:"x"`
+		Y         float64         `json:"y"`
+		Width     float64         `json:"width"`
+		Height    float64         `json:"height"`
+		Element   elementRefV2    `json:"element"`
+		Title     string          `json:"title"`     // GridLayoutRow
+		Collapsed bool            `json:"collapsed"` // GridLayoutRow
+		Elements  json.RawMessage `json:"elements"`  // GridLayoutRow
+	} `json:"spec"`
+}
+
+type elementRefV2 struct {
+	Name string `json:"name"`
+}
+
+// fetchAPIV2 fetches a dashboard of schema v2 and converts it into the classic dashboard
+// model. The layout of the dashboard is converted into grid positions of the panels, as
+// the browser cannot be used to find them for tabs and auto grids.
+func (d *Dashboard) fetchAPIV2(ctx context.Context, expandRows bool) (APIDashboardData, error) {
+	var (
+		dashboard dashboardV2
+		err       error
+	)
+
+	for _, version := range dashboardV2APIVersions {
+		apiURL, urlErr := d.apiURL("apis/dashboard.grafana.app", version, "namespaces", namespace(ctx), "dashboards", d.uid)
+		if urlErr != nil {
+			return APIDashboardData{}, urlErr
+		}
+
+		if err = d.doJSON(ctx, http.MethodGet, apiURL, nil, &dashboard); err == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		return APIDashboardData{}, fmt.Errorf("error fetching schema v2 dashboard: %w", err)
+	}
+
+	return dashboard.apiData(expandRows)
+}
+
+// apiData converts the dashboard into the classic dashboard model.
+func (d dashboardV2) apiData(expandRows bool) (APIDashboardData, error) {
+	apiData := APIDashboardData{
+		Title:       d.Spec.Title,
+		Description: d.Spec.Description,
+	}
+
+	for _, variable := range d.Spec.Variables {
+		apiData.Templating.List = append(apiData.Templating.List, variable.Spec)
+	}
+
+	builder := layoutBuilder{elements: make(map[string]int), expandRows: expandRows}
+
+	// Sort the elements to assign the same IDs to elements without ID on every request.
+	names := slices.Sorted(maps.Keys(d.Spec.Elements))
+	panels := make(map[string]Panel, len(names))
+	used := make(map[int]bool, len(names))
+
+	for _, name := range names {
+		panel := d.Spec.Elements[name].panel()
+		if panel.ID <= 0 {
+			// Element names are like panel-1, the ID is optional.
+			panel.ID, _ = strconv.Atoi(name[strings.LastIndex(name, "-")+1:])
+		}
+
+		if panel.ID > 0 && !used[panel.ID] {
+			used[panel.ID] = true
+		} else {
+			panel.ID = 0
+		}
+
+		panels[name] = panel
+	}
+
+	// Elements without a valid or unique ID get the next free IDs.
+	nextID := 1
+
+	for _, name := range names {
+		panel := panels[name]
+
+		for ; panel.ID == 0; nextID++ {
+			if !used[nextID] {
+				panel.ID = nextID
+				used[nextID] = true
+			}
+		}
+
+		builder.elements[name] = panel.ID
+		apiData.RowOrPanels = append(apiData.RowOrPanels, RowOrPanel{Panel: panel})
+	}
+
+	if _, err := builder.add(d.Spec.Layout, "", 0); err != nil {
+		return APIDashboardData{}, err
+	}
+
+	apiData.Layout = builder.items
+
+	return apiData, nil
+}
+
+// namespace returns the API namespace of the organization of the request.
+func namespace(ctx context.Context) string {
+	orgID := backend.PluginConfigFromContext(ctx).OrgID
+	if orgID <= 1 {
+		return "default"
+	}
+
+	return "org-" + strconv.FormatInt(orgID, 10)
+}
+
+// panel converts the element into a panel of the classic dashboard model.
+func (e elementV2) panel() Panel {
+	spec := e.Spec
+
+	panel := Panel{
+		ID:            spec.ID,
+		Type:          cmp.Or(spec.VizConfig.Group, spec.VizConfig.Kind),
+		Title:         spec.Title,
+		Description:   spec.Description,
+		Links:         spec.Links,
+		LibraryPanel:  spec.LibraryPanel,
+		Options:       spec.VizConfig.Spec.Options,
+		FieldConfig:   spec.VizConfig.Spec.FieldConfig,
+		Interval:      spec.Data.Spec.QueryOptions.Interval,
+		MaxDataPoints: spec.Data.Spec.QueryOptions.MaxDataPoints,
+	}
+
+	for _, query := range spec.Data.Spec.Queries {
+		datasource := DatasourceRef{
+			Type: cmp.Or(query.Spec.Query.Group, query.Spec.Query.Kind),
+			UID:  query.Spec.Query.Datasource.Name,
+		}
+
+		if query.Spec.Datasource != nil {
+			datasource = *query.Spec.Datasource
+		}
+
+		target := make(map[string]any, len(query.Spec.Query.Spec)+3)
+		for key, value := range query.Spec.Query.Spec {
+			target[key] = value
+		}
+
+		target["refId"] = query.Spec.RefID
+		target["hide"] = query.Spec.Hidden
+		target["datasource"] = map[string]any{"type": datasource.Type, "uid": datasource.UID}
+
+		panel.Targets = append(panel.Targets, target)
+
+		if panel.Datasource == nil {
+			panel.Datasource = &datasource
+		}
+	}
+
+	return panel
+}
+
+// layoutBuilder flattens the layout tree of a schema v2 dashboard into panels with
+// grid positions. Rows and tabs are converted into rows, which become the sections
+// of the report. Titles of nested rows and tabs are joined with their parents.
+type layoutBuilder struct {
+	elements   map[string]int
+	expandRows bool
+	items      []Panel
+	rows       int
+}
+
+// add appends the panels of the layout starting at grid position y and returns the
+// grid position below the layout.
+//
+//nolint:cyclop
+func (b *layoutBuilder) add(layout layoutV2, title string, y float64) (float64, error) {
+	switch layout.Kind {
+	case layoutGrid:
+		var spec struct {
+			Items []gridLayoutItemV2 `json:"items"`
+		}
+
+		if err := json.Unmarshal(layout.Spec, &spec); err != nil {
+			return 0, fmt.Errorf("error decoding %s: %w", layout.Kind, err)
+		}
+
+		return b.addGridItems(spec.Items, title, y)
+	case layoutRows:
+		var spec struct {
+			Rows []struct {
+				Spec struct {
+					Title    string   `json:"title"`
+					Collapse bool     `json:"collapse"`
+					Layout   layoutV2 `json:"layout"`
+				} `json:"spec"`
+			} `json:"rows"`
+		}
+
+		if err := json.Unmarshal(layout.Spec, &spec); err != nil {
+			return 0, fmt.Errorf("error decoding %s: %w", layout.Kind, err)
+		}
+
+		var err error
+
+		for _, row := range spec.Rows {
+			rowTitle := joinTitles(title, row.Spec.Title)
+			y = b.addRow(rowTitle, y)
+
+			if row.Spec.Collapse && !b.expandRows {
+				continue
+			}
+
+			if y, err = b.add(row.Spec.Layout, rowTitle, y); err != nil {
+				return 0, err
+			}
+		}
+
+		return y, nil
+	case layoutTabs:
+		var spec struct {
+			Tabs []struct {
+				Spec struct {
+					Title  string   `json:"title"`
+					Layout layoutV2 `json:"layout"`
+				} `json:"spec"`
+			} `json:"tabs"`
+		}
+
+		if err := json.Unmarshal(layout.Spec, &spec); err != nil {
+			return 0, fmt.Errorf("error decoding %s: %w", layout.Kind, err)
+		}
+
+		var err error
+
+		for _, tab := range spec.Tabs {
+			tabTitle := joinTitles(title, tab.Spec.Title)
+
+			if y, err = b.add(tab.Spec.Layout, tabTitle, b.addRow(tabTitle, y)); err != nil {
+				return 0, err
+			}
+		}
+
+		return y, nil
+	case layoutAutoGrid:
+		var spec struct {
+			MaxColumnCount int     `json:"maxColumnCount"`
+			RowHeightMode  string  `json:"rowHeightMode"`
+			RowHeight      float64 `json:"rowHeight"`
+			Items          []struct {
+				Spec struct {
+					Element elementRefV2 `json:"element"`
+				} `json:"spec"`
+			} `json:"items"`
+		}
+
+		if err := json.Unmarshal(layout.Spec, &spec); err != nil {
+			return 0, fmt.Errorf("error decoding %s: %w", layout.Kind, err)
+		}
+
+		columns := min(cmp.Or(spec.MaxColumnCount, 3), 24)
+		width := float64(24 / columns)
+
+		height, ok := autoGridRowHeights[spec.RowHeightMode]
+		if !ok {
+			// Custom row heights are given in pixels.
+			height = cmp.Or(float64(int(spec.RowHeight/scaleHeight)), autoGridRowHeights["standard"])
+		}
+
+		for i, item := range spec.Items {
+			b.addElement(item.Spec.Element, GridPos{
+				X: float64(i%columns) * width,
+				Y: y + float64(i/columns)*height,
+				W: width,
+				H: height,
+			})
+		}
+
+		return y + float64((len(spec.Items)+columns-1)/columns)*height, nil
+	default:
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedLayout, layout.Kind)
+	}
+}
+
+// addGridItems appends the items of a grid layout. Grid rows of v2alpha1 dashboards
+// contain grid items positioned relative to the row.
+func (b *layoutBuilder) addGridItems(items []gridLayoutItemV2, title string, y float64) (float64, error) {
+	bottom := y
+
+	for _, item := range items {
+		if item.Kind == gridLayoutRow {
+			var rowItems []gridLayoutItemV2
+			if err := json.Unmarshal(item.Spec.Elements, &rowItems); err != nil && len(item.Spec.Elements) > 0 {
+				return 0, fmt.Errorf("error decoding %s: %w", item.Kind, err)
+			}
+
+			rowTitle := joinTitles(title, item.Spec.Title)
+			rowY := b.addRow(rowTitle, max(bottom, y+item.Spec.Y))
+			bottom = rowY
+
+			if item.Spec.Collapsed && !b.expandRows {
+				continue
+			}
+
+			var err error
+			if bottom, err = b.addGridItems(rowItems, rowTitle, rowY); err != nil {
+				return 0, err
+			}
+
+			continue
+		}
+
+		gridPos := GridPos{X: item.Spec.X, Y: y + item.Spec.Y, W: item.Spec.Width, H: item.Spec.Height}
+		b.addElement(item.Spec.Element, gridPos)
+		bottom = max(bottom, gridPos.Y+gridPos.H)
+	}
+
+	return bottom, nil
+}
+
+// addRow appends a row at grid position y and returns the grid position below it.
+// Rows get negative IDs, as they are no elements of the dashboard.
+func (b *layoutBuilder) addRow(title string, y float64) float64 {
+	b.rows++
+	b.items = append(b.items, Panel{ID: -b.rows, Type: Row.String(), Title: title, GridPos: GridPos{Y: y, W: 24, H: 1}})
+
+	return y + 1
+}
+
+// addElement appends the panel of the element reference at the grid position.
+func (b *layoutBuilder) addElement(ref elementRefV2, gridPos GridPos) {
+	if id, ok := b.elements[ref.Name]; ok {
+		b.items = append(b.items, Panel{ID: id, GridPos: gridPos})
+	}
+}
+
+// joinTitles joins the titles of nested rows and tabs.
+func joinTitles(parent, title string) string {
+	if parent == "" {
+		return title
+	}
+
+	return parent + " / " + title
+}
@@ -0,0 +1,163 @@
+package dashboard_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/dashboard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func row(id int, title string, y float64) dashboard.Panel {
+	return dashboard.Panel{ID: id, Type: "row", Title: title, GridPos: dashboard.GridPos{Y: y, W: 24, H: 1}}
+}
+
+func item(id int, x, y, w, h float64) dashboard.Panel {
+	return dashboard.Panel{ID: id, GridPos: dashboard.GridPos{X: x, Y: y, W: w, H: h}}
+}
+
+func TestConvertDashboardV2Layout(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name       string
+		fixture    string
+		expandRows bool
+		expected   []dashboard.Panel
+	}{
+		{
+			name:    "grid with rows",
+			fixture: "grid.json",
+			expected: []dashboard.Panel{
+				item(1, 0, 0, 12, 8),
+				item(2, 12, 0, 12, 8),
+				row(-1, "Storage", 8),
+				item(3, 0, 9, 24, 6),
+				row(-2, "Internals", 15),
+			},
+		},
+		{
+			name:       "grid with expanded rows",
+			fixture:    "grid.json",
+			expandRows: true,
+			expected: []dashboard.Panel{
+				item(1, 0, 0, 12, 8),
+				item(2, 12, 0, 12, 8),
+				row(-1, "Storage", 8),
+				item(3, 0, 9, 24, 6),
+				row(-2, "Internals", 15),
+				item(4, 0, 16, 24, 4),
+			},
+		},
+		{
+			name:    "rows",
+			fixture: "rows.json",
+			expected: []dashboard.Panel{
+				row(-1, "Traffic", 0),
+				item(1, 0, 1, 16, 8),
+				item(2, 16, 1, 8, 8),
+				row(-2, "Runtime", 9),
+			},
+		},
+		{
+			name:       "expanded rows",
+			fixture:    "rows.json",
+			expandRows: true,
+			expected: []dashboard.Panel{
+				row(-1, "Traffic", 0),
+				item(1, 0, 1, 16, 8),
+				item(2, 16, 1, 8, 8),
+				row(-2, "Runtime", 9),
+				item(3, 0, 10, 24, 6),
+			},
+		},
+		{
+			name:    "tabs with nested rows and auto grid",
+			fixture: "tabs.json",
+			expected: []dashboard.Panel{
+				row(-1, "Summary", 0),
+				item(1, 0, 1, 24, 4),
+				row(-2, "Details", 5),
+				row(-3, "Details / Golden signals", 6),
+				item(2, 0, 7, 12, 5),
+				item(3, 12, 7, 12, 5),
+			},
+		},
+		{
+			name:    "auto grid with custom row height",
+			fixture: "autogrid.json",
+			expected: []dashboard.Panel{
+				item(1, 0, 0, 8, 10),
+				item(2, 8, 0, 8, 10),
+				item(3, 16, 0, 8, 10),
+				item(4, 0, 10, 8, 10),
+				item(5, 8, 10, 8, 10),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := os.ReadFile(filepath.Join("testdata", "schemav2", tc.fixture))
+			require.NoError(t, err)
+
+			apiData, err := dashboard.ConvertDashboardV2(data, tc.expandRows)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, apiData.Layout)
+		})
+	}
+}
+
+func TestConvertDashboardV2Panels(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "schemav2", "grid.json"))
+	require.NoError(t, err)
+
+	apiData, err := dashboard.ConvertDashboardV2(data, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Grid", apiData.Title)
+	require.Len(t, apiData.RowOrPanels, 4)
+
+	panel := apiData.RowOrPanels[0].Panel
+	assert.Equal(t, 1, panel.ID)
+	assert.Equal(t, "timeseries", panel.Type)
+	assert.Equal(t, "CPU", panel.Title)
+	assert.Equal(t, "1m", panel.Interval)
+	assert.Equal(t, 500, panel.MaxDataPoints)
+	assert.Equal(t, "percent", panel.FieldConfig.Defaults.Unit)
+	require.NotNil(t, panel.Datasource)
+	assert.Equal(t, dashboard.DatasourceRef{Type: "prometheus", UID: "prom"}, *panel.Datasource)
+	require.Len(t, panel.Targets, 1)
+	assert.Equal(t, "up", panel.Targets[0]["expr"])
+	assert.Equal(t, "A", panel.Targets[0]["refId"])
+}
+
+func TestConvertDashboardV2UniqueIDs(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "schemav2", "autogrid.json"))
+	require.NoError(t, err)
+
+	apiData, err := dashboard.ConvertDashboardV2(data, false)
+	require.NoError(t, err)
+
+	titles := make(map[int]string, len(apiData.RowOrPanels))
+	for _, rowOrPanel := range apiData.RowOrPanels {
+		assert.NotContains(t, titles, rowOrPanel.ID)
+
+		titles[rowOrPanel.ID] = rowOrPanel.Title
+	}
+
+	assert.Equal(t, map[int]string{1: "Nodes", 2: "Pods", 3: "CPU", 4: "Memory", 5: "Pods again"}, titles)
+}
+
+func TestConvertDashboardV2UnsupportedLayout(t *testing.T) {
+	t.Parallel()
+
+	_, err := dashboard.ConvertDashboardV2([]byte(`{"spec": {"layout": {"kind": "CanvasLayout", "spec": {}}}}`), false)
+	require.ErrorIs(t, err, dashboard.ErrUnsupportedLayout)
+}
@@ -0,0 +1,237 @@
+package dashboard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// statTemplate renders the values of a stat panel as tiles.
+var statTemplate = template.Must(template.New("stat").Parse(`
+<div class="stat-values">
+	{{- range .Values }}
+	<div class="stat-value stat-value-{{ $.ColorMode }}"{{ if eq $.ColorMode "background" "background_solid" }} style="background-color: {{ .Color }}"{{ end }}>
+		{{- if .Name }}
+		<div class="stat-value-name">{{ .Name }}</div>
+		{{- end }}
+		{{- if .Text }}
+		<div class="stat-value-text"{{ if eq $.ColorMode "value" }} style="color: {{ .Color }}"{{ end }}>{{ .Text }}</div>
+		{{- end }}
+	</div>
+	{{- end }}
+</div>`))
+
+// Legacy singlestat value names and their reducer counterparts.
+var legacyValueNames = map[string]string{
+	"current": "lastNotNull",
+	"avg":     "mean",
+	"min":     "min",
+	"max":     "max",
+	"total":   "sum",
+	"first":   "firstNotNull",
+	"delta":   "delta",
+	"diff":    "diff",
+	"range":   "range",
+}
+
+// maxStatValues limits the number of tiles of a stat panel.
+const maxStatValues = 100
+
+// StatValue is a single value of a stat panel.
+type StatValue struct {
+	Name  string
+	Text  string
+	Color template.CSS
+}
+
+// RenderStat queries the data of a stat or singlestat panel, reduces it using the
+// panel's reducer and renders the values as HTML tiles styled by the field config.
+//
+//nolint:cyclop
+func (d *Dashboard) RenderStat(ctx context.Context, dashData Data, panel Panel) (PanelHTML, error) {
+	frames, err := d.QueryData(ctx, dashData, panel)
+	if err != nil {
+		return PanelHTML{}, err
+	}
+
+	defaults, options := panel.statConfig()
+
+	calc := "lastNotNull"
+	if len(options.Calcs) > 0 {
+		calc = options.Calcs[0]
+	}
+
+	// The limit applies to the values of all fields, as in Grafana.
+	limit := maxStatValues
+	if options.Limit > 0 {
+		limit = min(options.Limit, limit)
+	}
+
+	var values []StatValue
+
+	for _, field := range numericFields(frames, defaults, options.Fields) {
+		if len(values) >= limit {
+			break
+		}
+
+		fieldValues := field.Values
+
+		if !options.Values {
+			value, ok := Reduce(calc, field.Values)
+			if !ok {
+				value = math.NaN()
+			}
+
+			fieldValues = []float64{value}
+		}
+
+		minValue, maxValue := fieldRange(defaults, field.Values)
+
+		for _, value := range fieldValues[:min(len(fieldValues), limit-len(values))] {
+			values = append(values, statValue(defaults, field.Name, value, minValue, maxValue))
+		}
+	}
+
+	if len(values) == 0 {
+		noValue := defaults.NoValue
+		if noValue == "" {
+			noValue = "No data"
+		}
+
+		values = append(values, StatValue{Text: noValue, Color: Color(defaultColor)})
+	}
+
+	textMode := panel.Options.TextMode
+
+	for i := range values {
+		switch {
+		case textMode == "value", (textMode == "auto" || textMode == "") && len(values) == 1:
+			values[i].Name = ""
+		case textMode == "name":
+			values[i].Text = ""
+		case textMode == "none":
+			values[i].Name, values[i].Text = "", ""
+		}
+	}
+
+	colorMode := panel.Options.ColorMode
+	if colorMode == "" {
+		colorMode = "value"
+	}
+
+	var buf bytes.Buffer
+
+	if err := statTemplate.Execute(&buf, map[string]any{"Values": values, "ColorMode": colorMode}); err != nil {
+		return PanelHTML{}, fmt.Errorf("error executing stat template of panel %d: %w", panel.ID, err)
+	}
+
+	d.logger.Debug("rendered stat panel natively", "panel_id", panel.ID, "values", len(values))
+
+	return PanelHTML{
+		Panel: panel,
+		HTML:  template.HTML(buf.String()), //nolint:gosec // rendered by html/template
+	}, nil
+}
+
+// statValue formats a single value using unit, decimals, mappings and thresholds.
+func statValue(defaults FieldDefaults, name string, value, minValue, maxValue float64) StatValue {
+	isNull := math.IsNaN(value)
+
+	text, color, mapped := defaults.MapValue(value, isNull)
+	if !mapped || text == "" {
+		switch {
+		case isNull && defaults.NoValue != "":
+			text = defaults.NoValue
+		case isNull:
+			text = "No data"
+		default:
+			text = FormatValue(value, defaults.Unit, defaults.Decimals)
+		}
+	}
+
+	if !mapped || color == "" {
+		switch {
+		case defaults.Color.Mode == "fixed" && defaults.Color.FixedColor != "":
+			color = defaults.Color.FixedColor
+		case !isNull:
+			color = defaults.Thresholds.ThresholdColor(value, minValue, maxValue)
+		}
+	}
+
+	if color == "" {
+		color = defaultColor
+	}
+
+	return StatValue{Name: name, Text: text, Color: Color(color)}
+}
+
+// fieldRange returns the min and max of the field, as configured or computed from the values.
+func fieldRange(defaults FieldDefaults, values []float64) (float64, float64) {
+	minValue, maxValue := 0.0, 100.0
+
+	if notNull := slices.DeleteFunc(slices.Clone(values), math.IsNaN); len(notNull) > 0 {
+		minValue, maxValue = slices.Min(notNull), slices.Max(notNull)
+	}
+
+	if defaults.Min != nil {
+		minValue = *defaults.Min
+	}
+
+	if defaults.Max != nil {
+		maxValue = *defaults.Max
+	}
+
+	return minValue, maxValue
+}
+
+// statConfig returns the field defaults and reduce options of a stat panel. The
+// options of legacy singlestat panels are converted to their stat counterparts.
+func (p Panel) statConfig() (FieldDefaults, ReduceOptions) {
+	defaults, options := p.FieldConfig.Defaults, p.Options.ReduceOptions
+
+	var valueName string
+	if json.Unmarshal(p.LegacyValueName, &valueName) != nil || valueName == "" {
+		return defaults, options
+	}
+
+	if calc, ok := legacyValueNames[valueName]; ok {
+		options.Calcs = []string{calc}
+	}
+
+	var format string
+	if json.Unmarshal(p.LegacyFormat, &format) == nil && defaults.Unit == "" {
+		defaults.Unit = format
+	}
+
+	var decimals int
+	if json.Unmarshal(p.LegacyDecimals, &decimals) == nil && defaults.Decimals == nil {
+		defaults.Decimals = &decimals
+	}
+
+	var (
+		thresholds string
+		colors     []string
+	)
+
+	if json.Unmarshal(p.LegacyThresholds, &thresholds) == nil && json.Unmarshal(p.LegacyColors, &colors) == nil &&
+		len(colors) > 0 && len(defaults.Thresholds.Steps) == 0 {
+		defaults.Thresholds.Steps = []ThresholdStep{{Color: colors[0]}}
+
+		for i, threshold := range strings.Split(thresholds, ",") {
+			value, err := strconv.ParseFloat(strings.TrimSpace(threshold), 64)
+			if err != nil || i+1 >= len(colors) {
+				break
+			}
+
+			defaults.Thresholds.Steps = append(defaults.Thresholds.Steps, ThresholdStep{Value: &value, Color: colors[i+1]})
+		}
+	}
+
+	return defaults, options
+}
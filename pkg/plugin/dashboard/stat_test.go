@@ -0,0 +1,133 @@
+package dashboard_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/dashboard"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatConfig(t *testing.T) {
+	t.Parallel()
+
+	var panel dashboard.Panel
+
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "singlestat",
+		"valueName": "avg",
+		"format": "percent",
+		"decimals": 1,
+		"thresholds": "50, 80",
+		"colors": ["green", "orange", "red"]
+	}`), &panel))
+
+	defaults, options := panel.StatConfig()
+
+	assert.Equal(t, []string{"mean"}, options.Calcs)
+	assert.Equal(t, "percent", defaults.Unit)
+	require.NotNil(t, defaults.Decimals)
+	assert.Equal(t, 1, *defaults.Decimals)
+	require.Len(t, defaults.Thresholds.Steps, 3)
+	assert.Nil(t, defaults.Thresholds.Steps[0].Value)
+	assert.Equal(t, "green", defaults.Thresholds.Steps[0].Color)
+	assert.InDelta(t, 80, *defaults.Thresholds.Steps[2].Value, 0)
+	assert.Equal(t, "red", defaults.Thresholds.Steps[2].Color)
+
+	// The field config of migrated panels takes precedence over the legacy options.
+	panel.FieldConfig.Defaults.Unit = "bytes"
+	panel.FieldConfig.Defaults.Thresholds.Steps = []dashboard.ThresholdStep{{Color: "blue"}}
+
+	defaults, _ = panel.StatConfig()
+
+	assert.Equal(t, "bytes", defaults.Unit)
+	assert.Equal(t, []dashboard.ThresholdStep{{Color: "blue"}}, defaults.Thresholds.Steps)
+
+	// Stat panels are used as is.
+	stat := dashboard.Panel{Type: "stat", Options: dashboard.PanelOptions{ReduceOptions: dashboard.ReduceOptions{Calcs: []string{"max"}}}}
+
+	_, options = stat.StatConfig()
+	assert.Equal(t, []string{"max"}, options.Calcs)
+}
+
+func TestRenderStat(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/ds/query" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"results": {"A": {"frames": [{"schema": {"fields": [
+			{"name": "cpu", "type": "number", "typeInfo": {"frame": "float64"}},
+			{"name": "mem", "type": "number", "typeInfo": {"frame": "float64"}}
+		]}, "data": {"values": [[1.234, 2.345, 3.456], [10, 20, 30]]}}]}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	one := 1
+
+	for _, tc := range []struct {
+		name     string
+		options  dashboard.ReduceOptions
+		decimals *int
+		expected []string
+	}{
+		{"last value of each field", dashboard.ReduceOptions{}, nil, []string{"3.46", "30"}},
+		{"reducer", dashboard.ReduceOptions{Calcs: []string{"max"}}, nil, []string{"3.46", "30"}},
+		{"decimals", dashboard.ReduceOptions{Calcs: []string{"mean"}}, &one, []string{"2.3", "20.0"}},
+		{"all values", dashboard.ReduceOptions{Values: true}, nil, []string{"1.23", "2.35", "3.46", "10", "20", "30"}},
+		{"limit across fields", dashboard.ReduceOptions{Values: true, Limit: 4}, nil, []string{"1.23", "2.35", "3.46", "10"}},
+		{"limit within first field", dashboard.ReduceOptions{Values: true, Limit: 2}, nil, []string{"1.23", "2.35"}},
+		{"limit of reduced values", dashboard.ReduceOptions{Limit: 1}, nil, []string{"3.46"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dash := dashboard.New(log.NewNullLogger(), config.DefaultConfig, server.Client(), nil, nil,
+				server.URL, "uid", url.Values{}, "token")
+
+			panel := dashboard.Panel{
+				ID:          1,
+				Type:        "stat",
+				Datasource:  &dashboard.DatasourceRef{Type: "prometheus", UID: "prom"},
+				Targets:     []map[string]any{{"refId": "A", "expr": "up"}},
+				Options:     dashboard.PanelOptions{ReduceOptions: tc.options, TextMode: "value"},
+				FieldConfig: dashboard.FieldConfig{Defaults: dashboard.FieldDefaults{Decimals: tc.decimals}},
+			}.WithModel()
+
+			to := time.Now()
+			from := to.Add(-time.Hour)
+			timeRange := dashboard.TimeRange{From: from.UnixMilli(), To: to.UnixMilli(), FromTime: from, ToTime: to}
+
+			html, err := dash.RenderStat(context.Background(), dashboard.Data{TimeRange: timeRange}, panel)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expected, statTexts(string(html.HTML)))
+		})
+	}
+}
+
+// statTexts returns the texts of the value tiles of the rendered stat panel.
+func statTexts(html string) []string {
+	var texts []string
+
+	for _, part := range strings.Split(html, `class="stat-value-text"`)[1:] {
+		_, text, _ := strings.Cut(part, ">")
+		text, _, _ = strings.Cut(text, "<")
+		texts = append(texts, text)
+	}
+
+	return texts
+}
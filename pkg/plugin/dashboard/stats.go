@@ -0,0 +1,111 @@
+package dashboard
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// Statistic is a single summary statistic of a series along with its formatted text.
+// Valid is false, if the series has no value to compute the statistic from.
+type Statistic struct {
+	Value float64
+	Text  string
+	Valid bool
+}
+
+// SeriesStats contains the summary statistics of a single series of a panel. The
+// percentiles are in the same order as the percentiles of the panel statistics.
+type SeriesStats struct {
+	Name        string
+	Min         Statistic
+	Max         Statistic
+	Mean        Statistic
+	Last        Statistic
+	Percentiles []Statistic
+}
+
+// PanelStats contains the summary statistics of all series of a time series panel.
+type PanelStats struct {
+	Panel
+	Percentiles []float64
+	Series      []SeriesStats
+}
+
+// PercentileNames returns the names of the percentiles like p95, e.g., for table headers.
+func (s PanelStats) PercentileNames() []string {
+	names := make([]string, len(s.Percentiles))
+	for i, p := range s.Percentiles {
+		names[i] = "p" + strconv.FormatFloat(p, 'f', -1, 64)
+	}
+
+	return names
+}
+
+// ComputeStats queries the data of a time series or graph panel and computes min, max,
+// mean, last and the given percentiles of each series. Values are formatted using
+// the unit and decimals of the field config of the panel.
+func (d *Dashboard) ComputeStats(ctx context.Context, dashData Data, panel Panel, percentiles []float64) (PanelStats, error) {
+	frames, err := d.QueryData(ctx, dashData, panel)
+	if err != nil {
+		return PanelStats{}, err
+	}
+
+	defaults, _ := panel.chartConfig()
+
+	stats := PanelStats{Panel: panel, Percentiles: percentiles}
+
+	for _, field := range numericFields(frames, defaults, "") {
+		stats.Series = append(stats.Series, seriesStats(field, defaults, percentiles))
+	}
+
+	d.logger.Debug("computed panel statistics", "panel_id", panel.ID, "series", len(stats.Series))
+
+	return stats, nil
+}
+
+// seriesStats computes the statistics of a single field. The unit and decimals of the
+// panel take precedence over the ones returned by the data source.
+func seriesStats(field FieldValues, defaults FieldDefaults, percentiles []float64) SeriesStats {
+	unit, decimals := defaults.Unit, defaults.Decimals
+
+	if field.Config != nil {
+		unit = cmp.Or(unit, field.Config.Unit)
+
+		if decimals == nil && field.Config.Decimals != nil {
+			fieldDecimals := int(*field.Config.Decimals)
+			decimals = &fieldDecimals
+		}
+	}
+
+	statistic := func(value float64, ok bool) Statistic {
+		if !ok {
+			return Statistic{Text: cmp.Or(defaults.NoValue, "-")}
+		}
+
+		return Statistic{Value: value, Text: FormatValue(value, unit, decimals), Valid: true}
+	}
+
+	reduce := func(calc string) Statistic {
+		return statistic(Reduce(calc, field.Values))
+	}
+
+	stats := SeriesStats{
+		Name:        field.Name,
+		Min:         reduce("min"),
+		Max:         reduce("max"),
+		Mean:        reduce("mean"),
+		Last:        reduce("lastNotNull"),
+		Percentiles: make([]Statistic, len(percentiles)),
+	}
+
+	notNull := slices.DeleteFunc(slices.Clone(field.Values), math.IsNaN)
+
+	for i, p := range percentiles {
+		stats.Percentiles[i] = statistic(Percentile(notNull, p), len(notNull) > 0)
+	}
+
+	return stats
+}
@@ -0,0 +1,152 @@
+package dashboard
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Regex for table options in the panel description like report:maxRows=50 or
+// report:columns="Host name,CPU".
+var tableOptionRegex = regexp.MustCompile(`report:(maxRows|columns|repeatHeader|zebra|wrap|landscapeColumns)=("[^"]*"|\S+)`)
+
+// TableOptions controls how the data of a table panel is printed.
+type TableOptions struct {
+	// MaxRows limits the number of rows, 0 means all rows.
+	MaxRows int
+	// Columns selects and orders the columns by their name, empty means all columns.
+	Columns []string
+	// RepeatHeader repeats the header of the table on every printed page.
+	RepeatHeader bool
+	// Zebra colors every second row.
+	Zebra bool
+	// Wrap wraps long cells instead of cutting them off.
+	Wrap bool
+	// LandscapeColumns prints tables with at least this number of columns on
+	// landscape pages, 0 disables landscape pages.
+	LandscapeColumns int
+}
+
+// Rows returns the number of rows of the table without the header.
+func (t PanelTable) Rows() int {
+	return max(len(t.Data)-1, 0)
+}
+
+// Truncated returns true if rows of the table were left out due to the max rows.
+func (t PanelTable) Truncated() bool {
+	return t.TotalRows > t.Rows()
+}
+
+// Landscape returns true if the table is wide enough to be printed on landscape pages.
+func (t PanelTable) Landscape() bool {
+	return t.Options.LandscapeColumns > 0 && len(t.Data) > 0 && len(t.Data[0]) >= t.Options.LandscapeColumns
+}
+
+// tableOptions returns the table options of the config, overridden by the options in
+// the description of the panel. Invalid options are logged and ignored.
+func (d *Dashboard) tableOptions(panel Panel) TableOptions {
+	options := TableOptions{
+		MaxRows:          d.conf.TableMaxRows,
+		RepeatHeader:     d.conf.TableRepeatHeader,
+		Zebra:            d.conf.TableZebra,
+		Wrap:             d.conf.TableWrap,
+		LandscapeColumns: d.conf.TableLandscapeColumns,
+	}
+
+	for _, match := range tableOptionRegex.FindAllStringSubmatch(panel.Description, -1) {
+		name, value := match[1], strings.Trim(match[2], `"`)
+
+		var err error
+
+		// Options are only changed if their value is valid.
+		setInt := func(option *int) {
+			var n int
+			if n, err = strconv.Atoi(value); err == nil && n < 0 {
+				err = strconv.ErrRange
+			}
+
+			if err == nil {
+				*option = n
+			}
+		}
+
+		setBool := func(option *bool) {
+			var b bool
+			if b, err = strconv.ParseBool(value); err == nil {
+				*option = b
+			}
+		}
+
+		switch name {
+		case "maxRows":
+			setInt(&options.MaxRows)
+		case "columns":
+			options.Columns = strings.Split(value, ",")
+			for i := range options.Columns {
+				options.Columns[i] = strings.TrimSpace(options.Columns[i])
+			}
+		case "repeatHeader":
+			setBool(&options.RepeatHeader)
+		case "zebra":
+			setBool(&options.Zebra)
+		case "wrap":
+			setBool(&options.Wrap)
+		case "landscapeColumns":
+			setInt(&options.LandscapeColumns)
+		}
+
+		if err != nil {
+			d.logger.Warn("invalid table option of panel", "panel_id", panel.ID, "option", name, "value", value, "err", err)
+		}
+	}
+
+	return options
+}
+
+// ApplyTableOptions selects the columns and limits the rows of the table data.
+// Selected columns that are not part of the table are ignored. If none of them is
+// part of the table, all columns are kept.
+func ApplyTableOptions(table PanelTable, options TableOptions) PanelTable {
+	table.Options = options
+	table.TotalRows = table.Rows()
+
+	if len(table.Data) == 0 {
+		return table
+	}
+
+	if indexes := columnIndexes(table.Data[0], options.Columns); len(indexes) > 0 {
+		data := make(PanelTableData, len(table.Data))
+
+		for i, row := range table.Data {
+			data[i] = make([]string, len(indexes))
+
+			for j, col := range indexes {
+				if col < len(row) {
+					data[i][j] = row[col]
+				}
+			}
+		}
+
+		table.Data = data
+	}
+
+	if options.MaxRows > 0 && table.Rows() > options.MaxRows {
+		table.Data = table.Data[:options.MaxRows+1]
+	}
+
+	return table
+}
+
+// columnIndexes returns the indexes of the columns in the given order.
+func columnIndexes(header []string, columns []string) []int {
+	indexes := make([]int, 0, len(columns))
+
+	for _, column := range columns {
+		if i := slices.Index(header, column); i >= 0 {
+			indexes = append(indexes, i)
+		}
+	}
+
+	return indexes
+}
@@ -0,0 +1,76 @@
+package dashboard_test
+
+import (
+	"testing"
+
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/dashboard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyTableOptions(t *testing.T) {
+	t.Parallel()
+
+	data := dashboard.PanelTableData{{"host", "cpu", "mem"}, {"a", "1", "10"}, {"b", "2", "20"}, {"c", "3", "30"}}
+
+	for _, tc := range []struct {
+		name      string
+		options   dashboard.TableOptions
+		expected  dashboard.PanelTableData
+		truncated bool
+	}{
+		{
+			"all rows and columns",
+			dashboard.TableOptions{},
+			data,
+			false,
+		},
+		{
+			"max rows",
+			dashboard.TableOptions{MaxRows: 2},
+			dashboard.PanelTableData{{"host", "cpu", "mem"}, {"a", "1", "10"}, {"b", "2", "20"}},
+			true,
+		},
+		{
+			"max rows above row count",
+			dashboard.TableOptions{MaxRows: 5},
+			data,
+			false,
+		},
+		{
+			"selected and ordered columns",
+			dashboard.TableOptions{Columns: []string{"mem", "unknown", "host"}},
+			dashboard.PanelTableData{{"mem", "host"}, {"10", "a"}, {"20", "b"}, {"30", "c"}},
+			false,
+		},
+		{
+			"unknown columns only",
+			dashboard.TableOptions{Columns: []string{"unknown"}},
+			data,
+			false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := dashboard.ApplyTableOptions(dashboard.PanelTable{Title: "Table", Data: data}, tc.options)
+
+			assert.Equal(t, tc.expected, actual.Data)
+			assert.Equal(t, 3, actual.TotalRows)
+			assert.Equal(t, tc.truncated, actual.Truncated())
+		})
+	}
+}
+
+func TestPanelTableLandscape(t *testing.T) {
+	t.Parallel()
+
+	table := dashboard.PanelTable{Data: dashboard.PanelTableData{{"a", "b", "c"}}}
+
+	assert.False(t, table.Landscape())
+
+	table.Options.LandscapeColumns = 3
+	assert.True(t, table.Landscape())
+
+	table.Options.LandscapeColumns = 4
+	assert.False(t, table.Landscape())
+}
@@ -0,0 +1,61 @@
+package dashboard
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// Text panel modes.
+const (
+	textModeMarkdown = "markdown"
+	textModeHTML     = "html"
+	textModeCode     = "code"
+)
+
+var (
+	// markdown renders GitHub flavoured markdown like the text panel in Grafana.
+	// Raw HTML is allowed as the output is always sanitized afterward.
+	markdown = goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(goldmarkhtml.WithUnsafe()),
+	)
+
+	// sanitizer strips scripts, event handlers and other unsafe content from the text panel HTML.
+	sanitizer = bluemonday.UGCPolicy().AllowAttrs("class").Globally()
+)
+
+// RenderText renders the content of a text panel into sanitized HTML.
+// Template variables in the content are replaced with their current values.
+func (d *Dashboard) RenderText(data Data, panel Panel) (PanelHTML, error) {
+	content := data.Interpolate(panel.Options.Content)
+
+	var buf bytes.Buffer
+
+	switch panel.Options.Mode {
+	case textModeHTML:
+		buf.WriteString(content)
+	case textModeCode:
+		fmt.Fprintf(&buf, `<pre><code class="language-%s">%s</code></pre>`,
+			html.EscapeString(panel.Options.Code.Language), html.EscapeString(content))
+	case textModeMarkdown, "":
+		if err := markdown.Convert([]byte(content), &buf); err != nil {
+			return PanelHTML{}, fmt.Errorf("error converting markdown of panel %d: %w", panel.ID, err)
+		}
+	default:
+		return PanelHTML{}, fmt.Errorf("%w: %s", ErrUnsupportedTextMode, panel.Options.Mode)
+	}
+
+	d.logger.Debug("rendered text panel natively", "panel_id", panel.ID, "mode", panel.Options.Mode)
+
+	return PanelHTML{
+		Panel: panel,
+		HTML:  template.HTML(sanitizer.SanitizeBytes(buf.Bytes())), //nolint:gosec // sanitized above
+	}, nil
+}
@@ -1,9 +1,12 @@
 package dashboard
 
 import (
+	"encoding/json"
 	"fmt"
+	"html/template"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/chrome"
@@ -20,6 +23,9 @@ func (p PanelType) String() string {
 		"text",
 		"graph",
 		"table",
+		"stat",
+		"timeseries",
+		"row",
 	}[p]
 }
 
@@ -28,6 +34,9 @@ const (
 	Text
 	Graph
 	Table
+	Stat
+	TimeSeries
+	Row
 )
 
 type Dashboard struct {
@@ -41,12 +50,34 @@ type Dashboard struct {
 	uid            string
 	values         url.Values
 	saToken        string
+
+	// renderer renders the PNG images of panels
+	renderer PanelRenderer
+
+	// queries caches the query results of panels and the default data source
+	queries sync.Map
 }
 
 type Data struct {
 	Title     string
 	TimeRange TimeRange
 	Panels    []Panel
+	Sections  []Section
+	Variables Variables
+	// Version is set, if the report is generated for a specific version of the dashboard.
+	Version *Version
+}
+
+// Section is a group of panels rendered together in the report. Each dashboard
+// row with panels is a section, headed by the title of the row.
+type Section struct {
+	Title string
+	// Y is the grid position at which the panels of the section start.
+	Y float64
+	// PanelIndexes are the indexes of the panels of the section in Data.Panels.
+	PanelIndexes []int
+	// PageBreak is true, if the section starts on a new page.
+	PageBreak bool
 }
 
 type BrowserData struct {
@@ -79,8 +110,60 @@ type APIDashboardData struct {
 	Description    string       `json:"description"`
 	VariableValues string       // Not present in the Grafana JSON structure. Enriched data passed used by the Tex templating
 	RowOrPanels    []RowOrPanel `json:"panels"`
+	Templating     Templating   `json:"templating"`
+
+	// Layout contains the panels and rows of the dashboard with their grid positions,
+	// when they are known from the API. It is nil, if the layout is taken from the browser.
+	Layout []Panel `json:"-"`
+
+	// Version is set, if a specific version of the dashboard has been fetched.
+	Version *Version `json:"-"`
 }
 
+// Templating represents the template variables of a Grafana dashboard.
+type Templating struct {
+	List []Variable `json:"list"`
+}
+
+// Variable represents a Grafana dashboard template variable.
+type Variable struct {
+	Name     string           `json:"name"`
+	AllValue string           `json:"allValue"`
+	Current  VariableOption   `json:"current"`
+	Options  []VariableOption `json:"options"`
+}
+
+// VariableOption represents a selected or selectable value of a template variable.
+type VariableOption struct {
+	Value VariableValue `json:"value"`
+}
+
+// VariableValue holds the value(s) of a template variable. Grafana stores
+// single values as string and multi values as array of strings.
+type VariableValue []string
+
+// UnmarshalJSON implements the json.Unmarshaler interface of VariableValue.
+func (v *VariableValue) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*v = VariableValue{value}
+
+		return nil
+	}
+
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("error decoding variable value: %w", err)
+	}
+
+	*v = values
+
+	return nil
+}
+
+// Variables maps the names of template variables to their current values.
+type Variables map[string][]string
+
 // RowOrPanel represents a container for Panels.
 type RowOrPanel struct {
 	Panel
@@ -90,10 +173,163 @@ type RowOrPanel struct {
 
 // Panel represents a Grafana dashboard panel.
 type Panel struct {
-	ID      int     `json:"id"`
-	Type    string  `json:"type"`
-	Title   string  `json:"title"`
-	GridPos GridPos `json:"gridPos"`
+	ID          int          `json:"id"`
+	Type        string       `json:"type"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Links       []PanelLink  `json:"links"`
+	GridPos     GridPos      `json:"gridPos"`
+	Options     PanelOptions `json:"options"`
+
+	// LibraryPanel is set, if the panel is linked to a library panel.
+	LibraryPanel *LibraryPanel `json:"libraryPanel"`
+
+	// RowTitle is the title of the row containing the panel, if any.
+	RowTitle string `json:"-"`
+
+	// Query related fields
+	Datasource    *DatasourceRef   `json:"datasource"`
+	Targets       []map[string]any `json:"targets"`
+	Interval      string           `json:"interval"`
+	MaxDataPoints int              `json:"maxDataPoints"`
+	FieldConfig   FieldConfig      `json:"fieldConfig"`
+
+	// Legacy singlestat and graph panel fields. Types vary across panel plugins
+	// of old dashboard models, so they are decoded on demand.
+	LegacyValueName  json.RawMessage `json:"valueName"`
+	LegacyFormat     json.RawMessage `json:"format"`
+	LegacyDecimals   json.RawMessage `json:"decimals"`
+	LegacyThresholds json.RawMessage `json:"thresholds"`
+	LegacyColors     json.RawMessage `json:"colors"`
+	LegacyLines      json.RawMessage `json:"lines"`
+	LegacyBars       json.RawMessage `json:"bars"`
+	LegacyPoints     json.RawMessage `json:"points"`
+	LegacyFill       json.RawMessage `json:"fill"`
+	LegacyLineWidth  json.RawMessage `json:"linewidth"`
+	LegacyYAxes      json.RawMessage `json:"yaxes"`
+	LegacyLegend     json.RawMessage `json:"legend"`
+
+	// hasModel is true, if the panel has been enriched with its model from the dashboard API.
+	hasModel bool
+}
+
+// PanelOptions represents the options of a Grafana dashboard panel.
+type PanelOptions struct {
+	// Text panel options
+	Mode    string      `json:"mode"`
+	Content string      `json:"content"`
+	Code    CodeOptions `json:"code"`
+
+	// Stat panel options
+	ReduceOptions ReduceOptions `json:"reduceOptions"`
+	ColorMode     string        `json:"colorMode"`
+	TextMode      string        `json:"textMode"`
+
+	// Time series panel options
+	Legend LegendOptions `json:"legend"`
+}
+
+// LegendOptions represents the legend options of a panel.
+type LegendOptions struct {
+	ShowLegend  *bool  `json:"showLegend"`
+	DisplayMode string `json:"displayMode"`
+}
+
+// ReduceOptions represents the options used to reduce the values of a field into a single value.
+type ReduceOptions struct {
+	Calcs  []string `json:"calcs"`
+	Fields string   `json:"fields"`
+	Values bool     `json:"values"`
+	Limit  int      `json:"limit"`
+}
+
+// DatasourceRef represents a reference to a Grafana data source. Older dashboard
+// models reference data sources by name instead of an object.
+type DatasourceRef struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+
+	// Name is set, if the data source is referenced by its name.
+	Name string `json:"-"`
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface of DatasourceRef.
+func (r *DatasourceRef) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*r = DatasourceRef{Name: name}
+
+		return nil
+	}
+
+	type datasourceRef DatasourceRef
+
+	var ref datasourceRef
+	if err := json.Unmarshal(data, &ref); err != nil {
+		return fmt.Errorf("error decoding datasource reference: %w", err)
+	}
+
+	*r = DatasourceRef(ref)
+
+	return nil
+}
+
+// FieldConfig represents the field config of a Grafana dashboard panel.
+type FieldConfig struct {
+	Defaults FieldDefaults `json:"defaults"`
+}
+
+// FieldDefaults represents the default field config applied to all fields of a panel.
+type FieldDefaults struct {
+	DisplayName string         `json:"displayName"`
+	Unit        string         `json:"unit"`
+	Decimals    *int           `json:"decimals"`
+	Min         *float64       `json:"min"`
+	Max         *float64       `json:"max"`
+	NoValue     string         `json:"noValue"`
+	Color       FieldColor     `json:"color"`
+	Thresholds  Thresholds     `json:"thresholds"`
+	Mappings    []ValueMapping `json:"mappings"`
+
+	// Custom holds the panel specific field config. Its structure depends on the
+	// panel type, so it is decoded on demand.
+	Custom json.RawMessage `json:"custom"`
+}
+
+// FieldColor represents the color scheme of a field.
+type FieldColor struct {
+	Mode       string `json:"mode"`
+	FixedColor string `json:"fixedColor"`
+}
+
+// Thresholds represents the thresholds of a field.
+type Thresholds struct {
+	Mode  string          `json:"mode"`
+	Steps []ThresholdStep `json:"steps"`
+}
+
+// ThresholdStep represents a single threshold. The value of the base step is null.
+type ThresholdStep struct {
+	Value *float64 `json:"value"`
+	Color string   `json:"color"`
+}
+
+// ValueMapping represents a value mapping of a field. Options are decoded
+// depending on the type of the mapping.
+type ValueMapping struct {
+	Type    string          `json:"type"`
+	Options json.RawMessage `json:"options"`
+}
+
+// CodeOptions represents the options of a text panel in code mode.
+type CodeOptions struct {
+	Language string `json:"language"`
+}
+
+// PanelLink represents a link of a Grafana dashboard panel.
+type PanelLink struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
 }
 
 // GridPos represents a Grafana dashboard panel position.
@@ -104,9 +340,24 @@ type GridPos struct {
 	Y float64 `json:"y"`
 }
 
-// IsSingleStat returns true if panel is of type SingleStat.
+// IsSingleStat returns true if panel is of type SingleStat or Stat.
 func (p Panel) IsSingleStat() bool {
-	return p.Is(SingleStat)
+	return p.Is(SingleStat) || p.Is(Stat)
+}
+
+// IsRow returns true if panel is a row.
+func (p Panel) IsRow() bool {
+	return p.Is(Row)
+}
+
+// IsText returns true if panel is of type Text.
+func (p Panel) IsText() bool {
+	return p.Is(Text)
+}
+
+// HasModel returns true if the panel model from the dashboard API is known.
+func (p Panel) HasModel() bool {
+	return p.hasModel
 }
 
 // IsPartialWidth If panel has width less than total allowable width.
@@ -133,15 +384,33 @@ type PanelImage struct {
 	Panel
 	Image    string
 	MimeType string
+
+	// Notice is shown above the panel, e.g., when the image does not show the
+	// requested version of the dashboard.
+	Notice string
 }
 
 func (p PanelImage) String() string {
 	return fmt.Sprintf("data:%s;base64,%s", p.MimeType, p.Image)
 }
 
+// PanelHTML is a panel rendered natively into HTML instead of an image.
+type PanelHTML struct {
+	Panel
+	HTML template.HTML
+}
+
 type PanelTable struct {
-	Title string
-	Data  PanelTableData
+	Title   string
+	Data    PanelTableData
+	Options TableOptions
+
+	// TotalRows is the number of rows before applying the max rows.
+	TotalRows int
+
+	// Notice is shown above the table, e.g., when the data does not come from the
+	// requested version of the dashboard.
+	Notice string
 }
 
 type PanelTableData [][]string
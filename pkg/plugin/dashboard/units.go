@@ -0,0 +1,194 @@
+package dashboard
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scale describes a unit whose values are scaled by a factor with a suffix per step.
+type scale struct {
+	factor   float64
+	suffixes []string
+	// offset is the index of the suffix of the unscaled value.
+	offset int
+}
+
+// Scaled units of Grafana.
+// Ref: https://github.com/grafana/grafana/blob/main/packages/grafana-data/src/valueFormats/categories.ts
+var scaledUnits = map[string]scale{
+	"short":     {1000, []string{"", " K", " Mil", " Bil", " Tri", " Quadr", " Quint", " Sext", " Sept"}, 0},
+	"bytes":     {1024, []string{" B", " KiB", " MiB", " GiB", " TiB", " PiB", " EiB"}, 0},
+	"kbytes":    {1024, []string{" B", " KiB", " MiB", " GiB", " TiB", " PiB", " EiB"}, 1},
+	"mbytes":    {1024, []string{" B", " KiB", " MiB", " GiB", " TiB", " PiB", " EiB"}, 2},
+	"gbytes":    {1024, []string{" B", " KiB", " MiB", " GiB", " TiB", " PiB", " EiB"}, 3},
+	"decbytes":  {1000, []string{" B", " kB", " MB", " GB", " TB", " PB", " EB"}, 0},
+	"deckbytes": {1000, []string{" B", " kB", " MB", " GB", " TB", " PB", " EB"}, 1},
+	"decmbytes": {1000, []string{" B", " kB", " MB", " GB", " TB", " PB", " EB"}, 2},
+	"decgbytes": {1000, []string{" B", " kB", " MB", " GB", " TB", " PB", " EB"}, 3},
+	"bits":      {1024, []string{" b", " Kib", " Mib", " Gib", " Tib", " Pib", " Eib"}, 0},
+	"decbits":   {1000, []string{" b", " kb", " Mb", " Gb", " Tb", " Pb", " Eb"}, 0},
+	"bps":       {1000, []string{" bps", " kbps", " Mbps", " Gbps", " Tbps", " Pbps"}, 0},
+	"Bps":       {1000, []string{" B/s", " kB/s", " MB/s", " GB/s", " TB/s", " PB/s"}, 0},
+	"binbps":    {1024, []string{" b/s", " Kib/s", " Mib/s", " Gib/s", " Tib/s", " Pib/s"}, 0},
+	"binBps":    {1024, []string{" B/s", " KiB/s", " MiB/s", " GiB/s", " TiB/s", " PiB/s"}, 0},
+	"hertz":     {1000, []string{" Hz", " kHz", " MHz", " GHz", " THz"}, 0},
+	"watt":      {1000, []string{" W", " kW", " MW", " GW"}, 0},
+	"kwatt":     {1000, []string{" W", " kW", " MW", " GW"}, 1},
+	"volt":      {1000, []string{" V", " kV", " MV"}, 0},
+	"amp":       {1000, []string{" A", " kA", " MA"}, 0},
+}
+
+// Units which are shortened values with a fixed suffix.
+var suffixUnits = map[string]string{
+	"reqps":      " req/s",
+	"rps":        " rd/s",
+	"wps":        " wr/s",
+	"iops":       " io/s",
+	"ops":        " ops/s",
+	"reqpm":      " req/min",
+	"opm":        " ops/min",
+	"celsius":    "°C",
+	"fahrenheit": "°F",
+	"kelvin":     " K",
+	"humidity":   "%H",
+	"degree":     "°",
+}
+
+// Currency units which are shortened values with a prefix.
+var currencyUnits = map[string]string{
+	"currencyUSD": "$",
+	"currencyEUR": "€",
+	"currencyGBP": "£",
+	"currencyJPY": "¥",
+	"currencyCHF": "CHF ",
+	"currencyINR": "₹",
+}
+
+// Units of time in seconds, used for the time units.
+var timeUnits = map[string]float64{
+	"ns": 1e-9,
+	"µs": 1e-6,
+	"us": 1e-6,
+	"ms": 1e-3,
+	"s":  1,
+	"m":  60,
+	"h":  3600,
+	"d":  86400,
+}
+
+// FormatValue formats the value like Grafana does with the given unit and decimals.
+// When decimals is nil, the number of decimals is derived from the value.
+// Unknown units are appended to the value as a custom suffix.
+//
+//nolint:cyclop
+func FormatValue(value float64, unit string, decimals *int) string {
+	if math.IsNaN(value) {
+		return "NaN"
+	}
+
+	if math.IsInf(value, 0) {
+		if value > 0 {
+			return "Inf"
+		}
+
+		return "-Inf"
+	}
+
+	if s, ok := scaledUnits[unit]; ok {
+		return formatScaled(value, s, decimals)
+	}
+
+	if suffix, ok := suffixUnits[unit]; ok {
+		return formatScaled(value, scaledUnits["short"], decimals) + suffix
+	}
+
+	if prefix, ok := currencyUnits[unit]; ok {
+		return prefix + strings.TrimSpace(formatScaled(value, scaledUnits["short"], decimals))
+	}
+
+	if seconds, ok := timeUnits[unit]; ok {
+		return formatDuration(value*seconds, decimals)
+	}
+
+	switch {
+	case unit == "", unit == "none":
+		return formatDecimals(value, decimals)
+	case unit == "percent":
+		return formatDecimals(value, decimals) + "%"
+	case unit == "percentunit":
+		return formatDecimals(value*100, decimals) + "%"
+	case unit == "bool":
+		return strconv.FormatBool(value != 0)
+	case unit == "dateTimeAsIso":
+		return time.UnixMilli(int64(value)).Format(time.RFC3339)
+	case strings.HasPrefix(unit, "suffix:"):
+		return formatDecimals(value, decimals) + strings.TrimPrefix(unit, "suffix:")
+	case strings.HasPrefix(unit, "prefix:"):
+		return strings.TrimPrefix(unit, "prefix:") + formatDecimals(value, decimals)
+	case strings.HasPrefix(unit, "si:"):
+		return formatScaled(value, scale{1000, []string{" ", " k", " M", " G", " T", " P"}, 0}, decimals) + strings.TrimPrefix(unit, "si:")
+	default:
+		return formatDecimals(value, decimals) + " " + unit
+	}
+}
+
+// formatScaled scales the value by the factor of the scale and appends the matching suffix.
+func formatScaled(value float64, s scale, decimals *int) string {
+	step := s.offset
+
+	for math.Abs(value) >= s.factor && step < len(s.suffixes)-1 {
+		value /= s.factor
+		step++
+	}
+
+	return formatDecimals(value, decimals) + s.suffixes[step]
+}
+
+// formatDuration formats a duration in seconds using the largest fitting unit of time.
+func formatDuration(seconds float64, decimals *int) string {
+	units := []struct {
+		seconds float64
+		suffix  string
+	}{
+		{365 * 86400, " year"},
+		{7 * 86400, " week"},
+		{86400, " day"},
+		{3600, " hour"},
+		{60, " min"},
+		{1, " s"},
+		{1e-3, " ms"},
+		{1e-6, " µs"},
+		{1e-9, " ns"},
+	}
+
+	for _, unit := range units {
+		if math.Abs(seconds) >= unit.seconds {
+			return formatDecimals(seconds/unit.seconds, decimals) + unit.suffix
+		}
+	}
+
+	return formatDecimals(seconds, decimals) + " s"
+}
+
+// formatDecimals formats the value with the given number of decimals. When decimals
+// is nil, around three significant digits are kept.
+func formatDecimals(value float64, decimals *int) string {
+	if decimals != nil {
+		return strconv.FormatFloat(value, 'f', max(*decimals, 0), 64)
+	}
+
+	if value == 0 || value == math.Trunc(value) {
+		return strconv.FormatFloat(value, 'f', 0, 64)
+	}
+
+	precision := max(2-int(math.Floor(math.Log10(math.Abs(value)))), 0)
+
+	formatted := strconv.FormatFloat(value, 'f', precision, 64)
+	if strings.Contains(formatted, ".") {
+		formatted = strings.TrimRight(strings.TrimRight(formatted, "0"), ".")
+	}
+
+	return formatted
+}
@@ -0,0 +1,44 @@
+package dashboard_test
+
+import (
+	"testing"
+
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/dashboard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatValue(t *testing.T) {
+	t.Parallel()
+
+	two := 2
+
+	for _, tc := range []struct {
+		name     string
+		value    float64
+		unit     string
+		decimals *int
+		expected string
+	}{
+		{"no unit", 12.3456, "", nil, "12.3"},
+		{"no unit with decimals", 12.3456, "none", &two, "12.35"},
+		{"integer", 42, "", nil, "42"},
+		{"short", 1234567, "short", nil, "1.23 Mil"},
+		{"percent", 97.5, "percent", nil, "97.5%"},
+		{"percentunit", 0.5, "percentunit", nil, "50%"},
+		{"bytes", 2048, "bytes", nil, "2 KiB"},
+		{"decbytes", 1500000, "decbytes", nil, "1.5 MB"},
+		{"kbytes", 2048, "kbytes", nil, "2 MiB"},
+		{"seconds", 90, "s", nil, "1.5 min"},
+		{"milliseconds", 250, "ms", nil, "250 ms"},
+		{"reqps", 1500, "reqps", nil, "1.5 K req/s"},
+		{"currency", 1500, "currencyUSD", nil, "$1.5 K"},
+		{"custom suffix", 5, "suffix:pods", nil, "5pods"},
+		{"unknown unit", 5, "widgets", nil, "5 widgets"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, dashboard.FormatValue(tc.value, tc.unit, tc.decimals))
+		})
+	}
+}
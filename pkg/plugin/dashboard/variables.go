@@ -0,0 +1,153 @@
+package dashboard
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Regex for template variables in the forms $var, [[var:format]] and ${var:format}.
+var variableRegex = regexp.MustCompile(`\$(\w+)|\[\[(\w+?)(?::(\w+))?\]\]|\$\{(\w+)(?::([^\}]+))?\}`)
+
+const (
+	variableAllValue  = "$__all"
+	variableURLPrefix = "var-"
+)
+
+// variables returns the current values of the dashboard template variables.
+// Values passed in the request query parameters take precedence over the ones
+// saved in the dashboard model.
+func (d *Dashboard) variables(apiData APIDashboardData) Variables {
+	variables := make(Variables, len(apiData.Templating.List))
+
+	for _, variable := range apiData.Templating.List {
+		values := []string(variable.Current.Value)
+		if d.values.Has(variableURLPrefix + variable.Name) {
+			values = d.values[variableURLPrefix+variable.Name]
+		}
+
+		if len(values) == 1 && values[0] == variableAllValue {
+			values = variable.allValues()
+		}
+
+		variables[variable.Name] = values
+	}
+
+	// Variables not defined in the model can still be passed in the query parameters.
+	for key, values := range d.values {
+		if name, ok := strings.CutPrefix(key, variableURLPrefix); ok {
+			if _, ok := variables[name]; !ok {
+				variables[name] = values
+			}
+		}
+	}
+
+	return variables
+}
+
+// allValues returns the values of the variable when "All" is selected.
+func (v Variable) allValues() []string {
+	if v.AllValue != "" {
+		return []string{v.AllValue}
+	}
+
+	values := make([]string, 0, len(v.Options))
+
+	for _, option := range v.Options {
+		for _, value := range option.Value {
+			if value != variableAllValue {
+				values = append(values, value)
+			}
+		}
+	}
+
+	return values
+}
+
+// Interpolate replaces the template variables in s with their current values.
+// Unknown variables are left untouched, like Grafana does.
+func (d Data) Interpolate(s string) string {
+	return d.interpolate(s, "")
+}
+
+// interpolate replaces the template variables in s with their current values
+// using defaultFormat for variables without an explicit format.
+func (d Data) interpolate(s string, defaultFormat string) string {
+	builtins := Variables{
+		"__dashboard": {d.Title},
+		"__from":      {strconv.FormatInt(d.TimeRange.FromTime.UnixMilli(), 10)},
+		"__to":        {strconv.FormatInt(d.TimeRange.ToTime.UnixMilli(), 10)},
+	}
+
+	return variableRegex.ReplaceAllStringFunc(s, func(match string) string {
+		groups := variableRegex.FindStringSubmatch(match)
+
+		name, format := groups[1], defaultFormat
+
+		switch {
+		case groups[2] != "":
+			name = groups[2]
+			format = cmp.Or(groups[3], defaultFormat)
+		case groups[4] != "":
+			name = groups[4]
+			format = cmp.Or(groups[5], defaultFormat)
+		}
+
+		values, ok := d.Variables[name]
+		if !ok {
+			if values, ok = builtins[name]; !ok {
+				return match
+			}
+		}
+
+		return formatVariable(name, values, format)
+	})
+}
+
+// formatVariable formats the values of a variable using the given Grafana format.
+// Ref: https://grafana.com/docs/grafana/latest/dashboards/variables/variable-syntax/#advanced-variable-format-options
+func formatVariable(name string, values []string, format string) string {
+	switch format {
+	case "pipe":
+		return strings.Join(values, "|")
+	case "json":
+		data, _ := json.Marshal(values) //nolint:errchkjson // marshalling strings does not fail
+
+		return string(data)
+	case "singlequote":
+		return "'" + strings.Join(values, "','") + "'"
+	case "doublequote":
+		return `"` + strings.Join(values, `","`) + `"`
+	case "queryparam":
+		query := make([]string, len(values))
+		for i, value := range values {
+			query[i] = variableURLPrefix + url.QueryEscape(name) + "=" + url.QueryEscape(value)
+		}
+
+		return strings.Join(query, "&")
+	case "percentencode":
+		return url.QueryEscape(strings.Join(values, ","))
+	case "regex":
+		if len(values) > 1 {
+			escaped := make([]string, len(values))
+			for i, value := range values {
+				escaped[i] = regexp.QuoteMeta(value)
+			}
+
+			return "(" + strings.Join(escaped, "|") + ")"
+		}
+
+		return strings.Join(values, ",")
+	case "glob":
+		if len(values) > 1 {
+			return "{" + strings.Join(values, ",") + "}"
+		}
+
+		return strings.Join(values, ",")
+	default:
+		return strings.Join(values, ",")
+	}
+}
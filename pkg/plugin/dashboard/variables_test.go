@@ -0,0 +1,87 @@
+package dashboard_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/dashboard"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpolate(t *testing.T) {
+	t.Parallel()
+
+	data := dashboard.Data{
+		Title: "My Dashboard",
+		TimeRange: dashboard.TimeRange{
+			FromTime: time.UnixMilli(1000),
+			ToTime:   time.UnixMilli(2000),
+		},
+		Variables: dashboard.Variables{
+			"host":   {"a", "b"},
+			"region": {"eu"},
+		},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain", "no variables", "no variables"},
+		{"dollar", "region $region", "region eu"},
+		{"braces", "region ${region}", "region eu"},
+		{"brackets", "region [[region]]", "region eu"},
+		{"multi value", "hosts $host", "hosts a,b"},
+		{"pipe format", "hosts ${host:pipe}", "hosts a|b"},
+		{"json format", "hosts ${host:json}", `hosts ["a","b"]`},
+		{"glob format", "hosts ${host:glob}", "hosts {a,b}"},
+		{"queryparam format", "${host:queryparam}", "var-host=a&var-host=b"},
+		{"builtin", "$__dashboard from $__from to $__to", "My Dashboard from 1000 to 2000"},
+		{"unknown", "unknown $missing", "unknown $missing"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, data.Interpolate(tc.input))
+		})
+	}
+}
+
+func TestVariables(t *testing.T) {
+	t.Parallel()
+
+	options := []dashboard.VariableOption{{Value: dashboard.VariableValue{"$__all"}}, {Value: dashboard.VariableValue{"All"}}, {Value: dashboard.VariableValue{"a"}}}
+
+	for _, tc := range []struct {
+		name     string
+		current  string
+		values   url.Values
+		expected []string
+	}{
+		{"current value", "a", url.Values{}, []string{"a"}},
+		{"query parameter", "a", url.Values{"var-host": {"All"}}, []string{"All"}},
+		{"all selected", "$__all", url.Values{}, []string{"All", "a"}},
+		{"all in query parameter", "a", url.Values{"var-host": {"$__all"}}, []string{"All", "a"}},
+		{"value named All", "All", url.Values{}, []string{"All"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dash := dashboard.New(log.NewNullLogger(), config.DefaultConfig, nil, nil, nil, "", "uid", tc.values, "")
+
+			variables := dash.Variables(dashboard.APIDashboardData{
+				Templating: dashboard.Templating{List: []dashboard.Variable{{
+					Name:    "host",
+					Current: dashboard.VariableOption{Value: dashboard.VariableValue{tc.current}},
+					Options: options,
+				}}},
+			})
+
+			assert.Equal(t, tc.expected, variables["host"])
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Version describes a saved version of a dashboard.
+type Version struct {
+	Version   int       `json:"version"`
+	Created   time.Time `json:"created"`
+	CreatedBy string    `json:"createdBy"`
+	Message   string    `json:"message"`
+}
+
+// fetchAPIVersion fetches the model of a given version of the dashboard using the
+// dashboard versions API. As the browser only shows the current version of the
+// dashboard, the layout is taken from the model of the version.
+func (d *Dashboard) fetchAPIVersion(ctx context.Context, version string, expandRows bool) (APIDashboardData, error) {
+	apiURL, err := d.apiURL("api/dashboards/uid", d.uid, "versions", version)
+	if err != nil {
+		return APIDashboardData{}, err
+	}
+
+	var dashboardVersion struct {
+		Version
+		Data APIDashboardData `json:"data"`
+	}
+
+	if err := d.doJSON(ctx, http.MethodGet, apiURL, nil, &dashboardVersion); err != nil {
+		return APIDashboardData{}, fmt.Errorf("error fetching version %s of dashboard: %w", version, err)
+	}
+
+	apiData := dashboardVersion.Data
+	apiData.Version = &dashboardVersion.Version
+	apiData.Layout = modelLayout(apiData, expandRows)
+
+	d.logger.Info("using dashboard version, panels rendered as images show the current version",
+		"version", dashboardVersion.Version.Version, "created", dashboardVersion.Created)
+
+	return apiData, nil
+}
+
+// CurrentVersionNotice returns the notice of panels rendered from the current version
+// of the dashboard, as images and tables are fetched from the dashboard in the browser.
+// Returns an empty string, if no specific version of the dashboard is requested.
+func (d Data) CurrentVersionNotice() string {
+	if d.Version == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("Rendered from the current version of the dashboard, not from version %d.", d.Version.Version)
+}
@@ -0,0 +1,16 @@
+package dashboard_test
+
+import (
+	"testing"
+
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/dashboard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurrentVersionNotice(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, dashboard.Data{}.CurrentVersionNotice())
+	assert.Equal(t, "Rendered from the current version of the dashboard, not from version 12.",
+		dashboard.Data{Version: &dashboard.Version{Version: 12}}.CurrentVersionNotice())
+}
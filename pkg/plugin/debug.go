@@ -0,0 +1,158 @@
+package plugin
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/chrome"
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/report"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// responseBuffer is a http.ResponseWriter, which keeps the report in memory, so that
+// it can be added to the debug bundle.
+type responseBuffer struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func newResponseBuffer() *responseBuffer {
+	return &responseBuffer{header: http.Header{}}
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	return b.body.Write(p) //nolint:wrapcheck
+}
+
+func (b *responseBuffer) WriteHeader(int) {}
+
+// generateDebugBundle generates the report and responds with a debug bundle instead of
+// the report. The bundle is returned even if the report fails, as it is meant to find
+// out why.
+func (app *App) generateDebugBundle(ctx context.Context, logger log.Logger, w http.ResponseWriter,
+	pdfReport *report.Report, dashboardUID string, conf config.Config,
+) {
+	diagnostics := &chrome.DiagnosticsLog{}
+	buf := newResponseBuffer()
+
+	err := pdfReport.Generate(chrome.WithDiagnosticsLog(ctx, diagnostics), buf)
+	if err != nil {
+		logger.Error("error generating report for debug bundle", "err", err)
+	}
+
+	bundle := debugBundle{
+		DashboardUID: dashboardUID,
+		Conf:         conf,
+		Report:       buf.body.Bytes(),
+		Err:          err,
+		Browser:      app.chromeInstance.Stats(),
+		Diagnostics:  diagnostics.Entries(),
+	}
+
+	if err = bundle.write(w); err != nil {
+		logger.Error("error writing debug bundle", "err", err)
+		http.Error(w, "error writing debug bundle", http.StatusInternalServerError)
+
+		return
+	}
+
+	logger.Info("debug bundle generated", "dash_uid", dashboardUID, "tabs", len(bundle.Diagnostics))
+}
+
+// debugBundle contains everything support needs to find out why a report failed: the
+// report or its error, the config, the state of the browser and the diagnostics of
+// each browser tab used for the report.
+type debugBundle struct {
+	DashboardUID string
+	Conf         config.Config
+	Report       []byte
+	Err          error
+	Browser      chrome.Stats
+	Diagnostics  []chrome.Diagnostics
+}
+
+// write writes the bundle as zip archive to the response.
+func (b debugBundle) write(w http.ResponseWriter) error {
+	diagnostics := make([]diagnosticsEntry, len(b.Diagnostics))
+	screenshots := make(map[string][]byte, len(b.Diagnostics))
+
+	for i, d := range b.Diagnostics {
+		diagnostics[i].Diagnostics = d
+
+		if len(d.Screenshot) > 0 {
+			diagnostics[i].Screenshot = fmt.Sprintf("screenshots/tab-%d.png", i+1)
+			screenshots[diagnostics[i].Screenshot] = d.Screenshot
+		}
+	}
+
+	browserJSON, err := json.MarshalIndent(b.Browser, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding browser stats: %w", err)
+	}
+
+	diagnosticsJSON, err := json.MarshalIndent(diagnostics, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding diagnostics: %w", err)
+	}
+
+	files := map[string][]byte{
+		"config.txt":       []byte(b.Conf.String()),
+		"browser.json":     browserJSON,
+		"diagnostics.json": diagnosticsJSON,
+	}
+
+	if b.Err != nil {
+		files["error.txt"] = []byte(b.Err.Error())
+	} else {
+		files["report.pdf"] = b.Report
+	}
+
+	maps.Copy(files, screenshots)
+
+	buf := &bytes.Buffer{}
+	archive := zip.NewWriter(buf)
+
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		f, err := archive.Create(name)
+		if err != nil {
+			return fmt.Errorf("error adding %s to debug bundle: %w", name, err)
+		}
+
+		if _, err = f.Write(files[name]); err != nil {
+			return fmt.Errorf("error writing %s to debug bundle: %w", name, err)
+		}
+	}
+
+	if err = archive.Close(); err != nil {
+		return fmt.Errorf("error closing debug bundle: %w", err)
+	}
+
+	filename := url.PathEscape("debug-" + b.DashboardUID)
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename*=UTF-8''%s.zip`, filename))
+
+	if _, err = w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("error writing debug bundle: %w", err)
+	}
+
+	return nil
+}
+
+// diagnosticsEntry are the diagnostics of a tab with the file name of its screenshot.
+type diagnosticsEntry struct {
+	chrome.Diagnostics
+
+	Screenshot string `json:"screenshot,omitempty"`
+}
@@ -0,0 +1,71 @@
+package plugin
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/chrome"
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugBundle(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		bundle   debugBundle
+		expected []string
+	}{
+		{
+			"failed report",
+			debugBundle{
+				DashboardUID: "uid",
+				Conf:         config.DefaultConfig,
+				Err:          errors.New("error waiting for #page-scrollbar"),
+				Diagnostics: []chrome.Diagnostics{
+					{URL: "http://grafana/d/uid", Screenshot: []byte("png")},
+					{URL: "http://grafana/d/uid?viewPanel=2"},
+				},
+			},
+			[]string{"browser.json", "config.txt", "diagnostics.json", "error.txt", "screenshots/tab-1.png"},
+		},
+		{
+			"successful report",
+			debugBundle{DashboardUID: "uid", Conf: config.DefaultConfig, Report: []byte("%PDF")},
+			[]string{"browser.json", "config.txt", "diagnostics.json", "report.pdf"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := httptest.NewRecorder()
+			require.NoError(t, tc.bundle.write(recorder))
+			assert.Equal(t, "application/zip", recorder.Header().Get("Content-Type"))
+
+			archive, err := zip.NewReader(bytes.NewReader(recorder.Body.Bytes()), int64(recorder.Body.Len()))
+			require.NoError(t, err)
+
+			names := make([]string, len(archive.File))
+			for i, file := range archive.File {
+				names[i] = file.Name
+			}
+
+			assert.Equal(t, tc.expected, names)
+
+			if tc.bundle.Err != nil {
+				f, err := archive.Open("error.txt")
+				require.NoError(t, err)
+
+				content, err := io.ReadAll(f)
+				require.NoError(t, err)
+				assert.Equal(t, tc.bundle.Err.Error(), string(content))
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package plugin
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Outcomes of report requests. Reports canceled by the client or rejected, because the
+// workers are busy, are counted apart from failed reports, as they do not indicate a
+// problem of the plugin.
+const (
+	reportGenerated = "generated"
+	reportFailed    = "failed"
+	reportCanceled  = "canceled"
+	reportRejected  = "rejected"
+)
+
+var reportsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "grafana_plugin",
+	Name:      "reporter_reports_total",
+	Help:      "Number of report requests by their outcome.",
+}, []string{"outcome"})
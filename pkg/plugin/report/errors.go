@@ -2,4 +2,8 @@ package report
 
 import "errors"
 
-var ErrEmptyDashboard = errors.New("empty dashboard model")
+var (
+	ErrEmptyDashboard  = errors.New("empty dashboard model")
+	ErrUnhealthyPanels = errors.New("report contains panels with query errors or no data")
+	ErrPartialPDF      = errors.New("PDF rendering failed after it was partially written")
+)
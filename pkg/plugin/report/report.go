@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"slices"
 	"sync"
+	"time"
 
 	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/chrome"
 	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
@@ -61,45 +62,148 @@ func (r *Report) Generate(ctx context.Context, writer http.ResponseWriter) error
 		return fmt.Errorf("failed to get dashboard data: %w", err)
 	}
 
+	panelHealths, err := r.checkPanels(ctx, dashboardData)
+	if err != nil {
+		return fmt.Errorf("failed to check panels: %w", err)
+	}
+
+	unhealthyPanels := slices.DeleteFunc(slices.Clone(panelHealths), dashboard.PanelHealth.Healthy)
+	if len(unhealthyPanels) > 0 {
+		r.logger.Warn("dashboard has unhealthy panels", "count", len(unhealthyPanels), "policy", r.conf.PanelErrorPolicy)
+	}
+
+	switch {
+	case len(unhealthyPanels) > 0 && r.conf.PanelErrorPolicy == "fail":
+		errs := make([]error, len(unhealthyPanels))
+		for i, health := range unhealthyPanels {
+			errs[i] = fmt.Errorf("panel %d (%s): %s", health.Panel.ID, health.Panel.Title, health.Message)
+		}
+
+		return fmt.Errorf("%w: %w", ErrUnhealthyPanels, errors.Join(errs...))
+	case r.conf.PanelErrorPolicy == "skip":
+		dashboardData = dashboardData.RemovePanels(func(i int, _ dashboard.Panel) bool {
+			return !panelHealths[i].Healthy()
+		})
+
+		panelHealths = slices.DeleteFunc(panelHealths, func(health dashboard.PanelHealth) bool {
+			return !health.Healthy()
+		})
+	}
+
+	current, err := r.renderPanels(ctx, r.dashboard, dashboardData)
+	if err != nil {
+		return fmt.Errorf("failed to generate report: %w", err)
+	}
+
+	current.Label = "Current period"
+	current.TimeRange = dashboardData.TimeRange
+	periods := []period{current}
+
+	if r.conf.CompareTo != "" {
+		previous, err := r.compare(ctx, dashboardData, &current)
+		if err != nil {
+			return fmt.Errorf("failed to generate comparison report: %w", err)
+		}
+
+		periods = []period{current, previous}
+	}
+
+	panelTables := slices.DeleteFunc(current.PanelTables, func(panelTable dashboard.PanelTable) bool {
+		return panelTable.Data == nil
+	})
+
+	// Sanitize title to escape non ASCII characters
+	// Ref: https://stackoverflow.com/questions/62705546/unicode-characters-in-attachment-name
+	// Ref: https://medium.com/@JeremyLaine/non-ascii-content-disposition-header-in-django-3a20acc05f0d
+	filename := url.PathEscape(dashboardData.Title)
+	header := fmt.Sprintf(`inline; filename*=UTF-8''%s.pdf`, filename)
+	writer.Header().Add("Content-Disposition", header)
+
+	htmlReport, err := r.generateHTMLFile(dashboardData, panelTables, periods, panelHealths, unhealthyPanels)
+	if err != nil {
+		return fmt.Errorf("failed to generate HTML file: %w", err)
+	}
+
+	if err = r.renderPDF(ctx, htmlReport, writer); err != nil {
+		return fmt.Errorf("failed to render PDF: %w", err)
+	}
+
+	return nil
+}
+
+// renderPanels renders all panels of the dashboard using the workers. Tables are fetched
+// as CSV data additionally.
+func (r *Report) renderPanels(ctx context.Context, dash *dashboard.Dashboard, dashboardData dashboard.Data) (period, error) {
+	// Jobs queued already are skipped, if another job cannot be queued.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	panelTables := make([]dashboard.PanelTable, len(dashboardData.Panels))
 	panelPNGs := make([]dashboard.PanelImage, len(dashboardData.Panels))
+	panelHTMLs := make([]dashboard.PanelHTML, len(dashboardData.Panels))
+	panelStats := make([]dashboard.PanelStats, len(dashboardData.Panels))
 	errorCh := make(chan error, len(dashboardData.Panels)*2)
 
-	wg := sync.WaitGroup{}
+	var (
+		wg       sync.WaitGroup
+		queueErr error
+	)
 
 	for idx, panel := range dashboardData.Panels {
 		if panel.Type == dashboard.Table.String() {
-			wg.Add(1)
-
-			r.pools[worker.Browser].Do(func() {
-				defer wg.Done()
-
-				panelTable, err := r.dashboard.FetchTable(ctx, panel)
+			queueErr = r.do(ctx, worker.Browser, &wg, func() {
+				panelTable, err := dash.FetchTable(ctx, panel)
 				if err != nil {
 					errorCh <- fmt.Errorf("failed to fetch CSV data for panel %d: %w", panel.ID, err)
 				}
 
+				panelTable.Notice = dashboardData.CurrentVersionNotice()
 				panelTables[idx] = panelTable
 			})
+			if queueErr != nil {
+				break
+			}
 		}
 
-		wg.Add(1)
+		queueErr = r.do(ctx, worker.Renderer, &wg, func() {
+			panelStats[idx] = r.computePanelStats(ctx, dash, dashboardData, panel)
+
+			// Panels rendered natively do not need an image.
+			if panelHTML, ok := r.renderPanelHTML(ctx, dash, dashboardData, panel); ok {
+				panelHTMLs[idx] = panelHTML
+				panelPNGs[idx] = dashboard.PanelImage{Panel: panel}
 
-		r.pools[worker.Renderer].Do(func() {
-			defer wg.Done()
+				return
+			}
 
-			panelPNG, err := r.dashboard.FetchPNG(ctx, panel)
+			panelPNG, err := dash.FetchPNG(ctx, panel)
 			if err != nil {
 				errorCh <- fmt.Errorf("failed to fetch PNG data for panel %d: %w", panel.ID, err)
 			}
 
+			panelPNG.Notice = dashboardData.CurrentVersionNotice()
 			panelPNGs[idx] = panelPNG
 		})
+		if queueErr != nil {
+			break
+		}
+	}
+
+	if queueErr != nil {
+		cancel()
 	}
 
 	wg.Wait()
 	close(errorCh)
 
+	if queueErr != nil {
+		return period{}, queueErr
+	}
+
+	if err := ctx.Err(); err != nil {
+		return period{}, err //nolint:wrapcheck
+	}
+
 	errs := make([]error, 0, len(dashboardData.Panels)*2)
 
 	for err := range errorCh {
@@ -107,47 +211,259 @@ func (r *Report) Generate(ctx context.Context, writer http.ResponseWriter) error
 	}
 
 	if len(errs) > 0 {
-		return fmt.Errorf("failed to generate report: %w", errors.Join(errs...))
+		return period{}, errors.Join(errs...)
 	}
 
-	panelTables = slices.DeleteFunc(panelTables, func(panelTable dashboard.PanelTable) bool {
-		return panelTable.Data == nil
-	})
+	return period{
+		PanelTables: panelTables,
+		PanelPNGs:   panelPNGs,
+		PanelHTMLs:  panelHTMLs,
+		PanelStats:  panelStats,
+		StatDeltas:  make([][]dashboard.Delta, len(dashboardData.Panels)),
+	}, nil
+}
 
-	// Sanitize title to escape non ASCII characters
-	// Ref: https://stackoverflow.com/questions/62705546/unicode-characters-in-attachment-name
-	// Ref: https://medium.com/@JeremyLaine/non-ascii-content-disposition-header-in-django-3a20acc05f0d
-	filename := url.PathEscape(dashboardData.Title)
-	header := fmt.Sprintf(`inline; filename*=UTF-8''%s.pdf`, filename)
-	writer.Header().Add("Content-Disposition", header)
+// compare renders all panels for the comparison period. The tables of the current
+// period get delta columns and the changes of stat panels are computed.
+func (r *Report) compare(ctx context.Context, dashboardData dashboard.Data, current *period) (period, error) {
+	compareData := dashboardData
+	compareData.TimeRange = dashboard.NewTimeRange(
+		r.conf.CompareTime(dashboardData.TimeRange.FromTime),
+		r.conf.CompareTime(dashboardData.TimeRange.ToTime),
+	)
 
-	htmlReport, err := r.generateHTMLFile(dashboardData, panelTables, panelPNGs)
+	r.logger.Info("render comparison period", "compare_to", r.conf.CompareTo,
+		"from", compareData.TimeRange.FromTime, "to", compareData.TimeRange.ToTime)
+
+	previous, err := r.renderPanels(ctx, r.dashboard.WithTimeRange(compareData.TimeRange), compareData)
 	if err != nil {
-		return fmt.Errorf("failed to generate HTML file: %w", err)
+		return period{}, err
 	}
 
-	if err = r.renderPDF(htmlReport, writer); err != nil {
-		return fmt.Errorf("failed to render PDF: %w", err)
+	previous.Label = fmt.Sprintf("Previous period (%s)", r.conf.CompareTo)
+	previous.TimeRange = compareData.TimeRange
+	previous.Suffix = "-previous"
+
+	// Jobs queued already are skipped, if another job cannot be queued.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		queueErr error
+	)
+
+	for idx, panel := range dashboardData.Panels {
+		if current.PanelTables[idx].Data != nil && previous.PanelTables[idx].Data != nil {
+			current.PanelTables[idx] = dashboard.CompareTable(current.PanelTables[idx], previous.PanelTables[idx])
+		}
+
+		if !panel.IsSingleStat() || !panel.HasModel() {
+			continue
+		}
+
+		queueErr = r.do(ctx, worker.Renderer, &wg, func() {
+			deltas, err := r.dashboard.CompareStat(ctx, dashboardData, compareData, panel)
+			if err != nil {
+				r.logger.Warn("failed to compare stat panel", "panel_id", panel.ID, "err", err)
+
+				return
+			}
+
+			current.StatDeltas[idx] = deltas
+		})
+		if queueErr != nil {
+			break
+		}
+	}
+
+	if queueErr != nil {
+		cancel()
+	}
+
+	wg.Wait()
+
+	if queueErr != nil {
+		return period{}, queueErr
+	}
+
+	if err := ctx.Err(); err != nil {
+		return period{}, err //nolint:wrapcheck
+	}
+
+	return previous, nil
+}
+
+// checkPanels checks the data of all panels using the renderer workers. Panels are
+// not checked, if the panel error policy is off, as every check queries the data
+// sources of the panel.
+func (r *Report) checkPanels(ctx context.Context, dashboardData dashboard.Data) ([]dashboard.PanelHealth, error) {
+	panelHealths := make([]dashboard.PanelHealth, len(dashboardData.Panels))
+
+	if r.conf.PanelErrorPolicy == "off" {
+		for idx, panel := range dashboardData.Panels {
+			panelHealths[idx] = dashboard.PanelHealth{Panel: panel, Status: dashboard.PanelStatusUnknown}
+		}
+
+		return panelHealths, nil
+	}
+
+	// Jobs queued already are skipped, if another job cannot be queued.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		queueErr error
+	)
+
+	for idx, panel := range dashboardData.Panels {
+		queueErr = r.do(ctx, worker.Renderer, &wg, func() {
+			panelHealths[idx] = r.dashboard.CheckPanel(ctx, dashboardData, panel)
+		})
+		if queueErr != nil {
+			break
+		}
+	}
+
+	if queueErr != nil {
+		cancel()
+	}
+
+	wg.Wait()
+
+	if queueErr != nil {
+		return nil, queueErr
+	}
+
+	// Health checks of canceled requests are incomplete
+	if err := ctx.Err(); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return panelHealths, nil
+}
+
+// do runs f on the worker pool and marks it done in wg. Jobs of canceled requests are
+// skipped. If the job is rejected, e.g., because the queue is full, f is not run.
+func (r *Report) do(ctx context.Context, pool string, wg *sync.WaitGroup, f func()) error {
+	wg.Add(1)
+
+	err := r.pools[pool].Do(ctx, func() {
+		defer wg.Done()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		f()
+	})
+	if err != nil {
+		wg.Done()
+
+		return fmt.Errorf("failed to queue job: %w", err)
 	}
 
 	return nil
 }
 
-// renderPDF renders HTML page into PDF using Chromium.
-func (r *Report) renderPDF(htmlReport HTML, writer io.Writer) error {
-	// Create a new tab
-	tab := r.chromeInstance.NewTab(r.logger, r.conf)
-	defer tab.Close(r.logger)
-
-	err := tab.PrintToPDF(chrome.PDFOptions{
-		Header:      htmlReport.Header,
-		Body:        htmlReport.Body,
-		Footer:      htmlReport.Footer,
-		Orientation: r.conf.Orientation,
-	}, writer)
+// renderPanelHTML renders the panel natively into HTML, if it is supported for the panel.
+// Returns false if the panel must be rendered as image instead. Reports of a specific
+// version of the dashboard always render stat and time series panels natively, as
+// images show the current version of the dashboard.
+func (r *Report) renderPanelHTML(ctx context.Context, dash *dashboard.Dashboard, dashboardData dashboard.Data, panel dashboard.Panel) (dashboard.PanelHTML, bool) {
+	if !panel.HasModel() {
+		return dashboard.PanelHTML{}, false
+	}
+
+	var (
+		panelHTML dashboard.PanelHTML
+		err       error
+	)
+
+	switch {
+	case panel.IsText():
+		panelHTML, err = dash.RenderText(dashboardData, panel)
+	case panel.IsSingleStat() && (r.conf.NativeStatPanels || dashboardData.Version != nil):
+		panelHTML, err = dash.RenderStat(ctx, dashboardData, panel)
+	case panel.IsTimeSeries() && (r.conf.VectorCharts || dashboardData.Version != nil):
+		panelHTML, err = dash.RenderChart(ctx, dashboardData, panel)
+	default:
+		return dashboard.PanelHTML{}, false
+	}
+
+	if err != nil {
+		r.logger.Warn("failed to render panel natively, falling back to PNG", "panel_id", panel.ID, "type", panel.Type, "err", err)
+
+		return dashboard.PanelHTML{}, false
+	}
+
+	return panelHTML, true
+}
+
+// computePanelStats computes the statistics of time series panels, if enabled. Panels
+// whose statistics cannot be computed are rendered without them.
+func (r *Report) computePanelStats(ctx context.Context, dash *dashboard.Dashboard, dashboardData dashboard.Data, panel dashboard.Panel) dashboard.PanelStats {
+	if !r.conf.PanelStats || !panel.IsTimeSeries() || !panel.HasModel() {
+		return dashboard.PanelStats{Panel: panel}
+	}
+
+	panelStats, err := dash.ComputeStats(ctx, dashboardData, panel, r.conf.StatsPercentiles)
 	if err != nil {
+		r.logger.Warn("failed to compute panel statistics", "panel_id", panel.ID, "err", err)
+
+		return dashboard.PanelStats{Panel: panel}
+	}
+
+	return panelStats
+}
+
+// renderPDF renders HTML page into PDF using Chromium. If the browser crashes before
+// any part of the PDF has been written, the PDF is rendered once more.
+func (r *Report) renderPDF(ctx context.Context, htmlReport HTML, writer io.Writer) error {
+	counter := &countingWriter{writer: writer}
+
+	// The page setup is validated along with the config.
+	pageSetup, _ := r.conf.PageSetup()
+
+	render := func() error {
+		// Create a new tab
+		tab := r.chromeInstance.NewTab(ctx, r.logger, r.conf)
+		tab.WithTimeout(time.Duration(r.conf.PDFTimeout))
+
+		defer tab.Close(r.logger)
+
+		err := tab.PrintToPDF(chrome.PDFOptions{
+			Header:      htmlReport.Header,
+			Body:        htmlReport.Body,
+			Footer:      htmlReport.Footer,
+			Orientation: r.conf.Orientation,
+			Page:        pageSetup,
+		}, counter)
+		if err != nil && counter.written > 0 {
+			// The response is already partially written, so it must not be retried.
+			return fmt.Errorf("%w: %v", ErrPartialPDF, err) //nolint:errorlint
+		}
+
+		return err
+	}
+
+	if err := chrome.RetryOnCrash(ctx, r.chromeInstance, r.logger, render); err != nil {
 		return fmt.Errorf("error rendering PDF: %w", err)
 	}
 
 	return nil
 }
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	writer  io.Writer
+	written int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.written += int64(n)
+
+	return n, err //nolint:wrapcheck
+}
@@ -13,7 +13,9 @@ import (
 // generateHTMLFile generates HTML files for PDF.
 //
 //nolint:cyclop
-func (r *Report) generateHTMLFile(dashboardData dashboard.Data, panelTables []dashboard.PanelTable, panelPNGs []dashboard.PanelImage) (HTML, error) {
+func (r *Report) generateHTMLFile(dashboardData dashboard.Data, panelTables []dashboard.PanelTable, periods []period,
+	panelHealths []dashboard.PanelHealth, unhealthyPanels []dashboard.PanelHealth,
+) (HTML, error) {
 	var (
 		err  error
 		html HTML
@@ -27,6 +29,10 @@ func (r *Report) generateHTMLFile(dashboardData dashboard.Data, panelTables []da
 			return i + 1
 		},
 
+		"sub": func(i, j float64) float64 {
+			return i - j
+		},
+
 		"mult": func(i int) int {
 			return i*30 + 5
 		},
@@ -61,13 +67,22 @@ func (r *Report) generateHTMLFile(dashboardData dashboard.Data, panelTables []da
 		return HTML{}, fmt.Errorf("error parsing PDF template: %w", err)
 	}
 
+	// The page setup is validated along with the config.
+	pageSetup, _ := r.conf.PageSetup()
+
 	// Template data
 	data := templateData{
 		time.Now().Format(time.RFC850),
 		dashboardData,
 		panelTables,
-		panelPNGs,
+		periods[0].PanelPNGs,
+		periods[0].PanelHTMLs,
+		periods[0].PanelStats,
 		r.conf,
+		pageSetup,
+		periods,
+		panelHealths,
+		unhealthyPanels,
 	}
 
 	// Render the template for Body of the PDF
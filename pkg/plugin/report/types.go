@@ -11,6 +11,20 @@ type HTML struct {
 	Footer string
 }
 
+// period contains the rendered panels for a time range, indexed like the panels of the dashboard.
+type period struct {
+	Label       string
+	TimeRange   dashboard.TimeRange
+	PanelTables []dashboard.PanelTable
+	PanelPNGs   []dashboard.PanelImage
+	PanelHTMLs  []dashboard.PanelHTML
+	PanelStats  []dashboard.PanelStats
+	StatDeltas  [][]dashboard.Delta
+
+	// Suffix keeps the element IDs of the panels unique.
+	Suffix string
+}
+
 // Data structures used inside HTML template.
 type templateData struct {
 	Date string
@@ -18,5 +32,17 @@ type templateData struct {
 	Dashboard   dashboard.Data
 	PanelTables []dashboard.PanelTable
 	PanelPNGs   []dashboard.PanelImage
+	PanelHTMLs  []dashboard.PanelHTML
+	PanelStats  []dashboard.PanelStats
 	Conf        config.Config
+	Page        config.PageSetup
+
+	// Periods contains the rendered panels of the current period and, in comparison
+	// mode, of the previous period.
+	Periods []period
+
+	// PanelHealths is the health of each panel and UnhealthyPanels lists the panels
+	// with query errors or no data, including the skipped ones.
+	PanelHealths    []dashboard.PanelHealth
+	UnhealthyPanels []dashboard.PanelHealth
 }
@@ -1,17 +1,27 @@
 package plugin
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/config"
 	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/dashboard"
 	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/report"
+	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/worker"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 )
 
+// queueRetryAfter is the number of seconds clients are asked to wait, when the
+// workers are too busy to accept a report.
+const queueRetryAfter = "30"
+
 // handleReport handles creating a PDF report from a given dashboard UID
 // GET /api/plugins/cloudeteer-pdfreport-app/resources/report.
 //
@@ -53,6 +63,28 @@ func (app *App) handleReport(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// Get Dashboard version, if any
+	if req.URL.Query().Has("dashVersion") {
+		if version, err := strconv.Atoi(req.URL.Query().Get("dashVersion")); err != nil || version < 1 {
+			ctxLogger.Debug("invalid dashVersion parameter: " + req.URL.Query().Get("dashVersion"))
+			http.Error(w, "invalid dashVersion parameter: "+req.URL.Query().Get("dashVersion"), http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	// Return a debug bundle instead of the report, if requested
+	var debug bool
+
+	if req.URL.Query().Has("debug") {
+		if debug, err = strconv.ParseBool(req.URL.Query().Get("debug")); err != nil {
+			ctxLogger.Debug("invalid debug parameter: " + err.Error())
+			http.Error(w, "invalid debug parameter: "+err.Error(), http.StatusBadRequest)
+
+			return
+		}
+	}
+
 	grafanaConfig := backend.GrafanaConfigFromContext(req.Context())
 
 	if req.URL.Query().Has("theme") {
@@ -95,6 +127,168 @@ func (app *App) handleReport(w http.ResponseWriter, req *http.Request) {
 		}
 	}
 
+	if req.URL.Query().Has("nativeStatPanels") {
+		conf.NativeStatPanels, err = strconv.ParseBool(req.URL.Query().Get("nativeStatPanels"))
+		if err != nil {
+			ctxLogger.Debug("invalid nativeStatPanels parameter: " + err.Error())
+			http.Error(w, "invalid nativeStatPanels parameter: "+err.Error(), http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	if req.URL.Query().Has("vectorCharts") {
+		conf.VectorCharts, err = strconv.ParseBool(req.URL.Query().Get("vectorCharts"))
+		if err != nil {
+			ctxLogger.Debug("invalid vectorCharts parameter: " + err.Error())
+			http.Error(w, "invalid vectorCharts parameter: "+err.Error(), http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	if req.URL.Query().Has("rowsOnNewPage") {
+		conf.RowsOnNewPage, err = strconv.ParseBool(req.URL.Query().Get("rowsOnNewPage"))
+		if err != nil {
+			ctxLogger.Debug("invalid rowsOnNewPage parameter: " + err.Error())
+			http.Error(w, "invalid rowsOnNewPage parameter: "+err.Error(), http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	if req.URL.Query().Has("panelErrorPolicy") {
+		conf.PanelErrorPolicy = req.URL.Query().Get("panelErrorPolicy")
+	}
+
+	if req.URL.Query().Has("panelStats") {
+		conf.PanelStats, err = strconv.ParseBool(req.URL.Query().Get("panelStats"))
+		if err != nil {
+			ctxLogger.Debug("invalid panelStats parameter: " + err.Error())
+			http.Error(w, "invalid panelStats parameter: "+err.Error(), http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	if req.URL.Query().Has("statsPercentile") {
+		conf.StatsPercentiles = make([]float64, len(req.URL.Query()["statsPercentile"]))
+
+		for i, stringPercentile := range req.URL.Query()["statsPercentile"] {
+			conf.StatsPercentiles[i], err = strconv.ParseFloat(stringPercentile, 64)
+			if err != nil {
+				ctxLogger.Debug("invalid statsPercentile parameter: " + err.Error())
+				http.Error(w, "invalid statsPercentile parameter: "+err.Error(), http.StatusBadRequest)
+
+				return
+			}
+		}
+	}
+
+	if req.URL.Query().Has("compareTo") {
+		conf.CompareTo = req.URL.Query().Get("compareTo")
+	}
+
+	if req.URL.Query().Has("compareLayout") {
+		conf.CompareLayout = req.URL.Query().Get("compareLayout")
+	}
+
+	for param, option := range map[string]*int{
+		"tableMaxRows":          &conf.TableMaxRows,
+		"tableLandscapeColumns": &conf.TableLandscapeColumns,
+		"retryMaxAttempts":      &conf.RetryMaxAttempts,
+	} {
+		if req.URL.Query().Has(param) {
+			if *option, err = strconv.Atoi(req.URL.Query().Get(param)); err != nil {
+				ctxLogger.Debug(fmt.Sprintf("invalid %s parameter: %s", param, err))
+				http.Error(w, fmt.Sprintf("invalid %s parameter: %s", param, err), http.StatusBadRequest)
+
+				return
+			}
+		}
+	}
+
+	for param, option := range map[string]*bool{
+		"tableRepeatHeader": &conf.TableRepeatHeader,
+		"tableZebra":        &conf.TableZebra,
+		"tableWrap":         &conf.TableWrap,
+		"printBackground":   &conf.PrintBackground,
+	} {
+		if req.URL.Query().Has(param) {
+			if *option, err = strconv.ParseBool(req.URL.Query().Get(param)); err != nil {
+				ctxLogger.Debug(fmt.Sprintf("invalid %s parameter: %s", param, err))
+				http.Error(w, fmt.Sprintf("invalid %s parameter: %s", param, err), http.StatusBadRequest)
+
+				return
+			}
+		}
+	}
+
+	for param, option := range map[string]*string{
+		"paperSize":   &conf.PaperSize,
+		"pageMargins": &conf.PageMargins,
+		"pageRanges":  &conf.PageRanges,
+	} {
+		if req.URL.Query().Has(param) {
+			*option = req.URL.Query().Get(param)
+		}
+	}
+
+	if req.URL.Query().Has("pageScale") {
+		if conf.PageScale, err = strconv.ParseFloat(req.URL.Query().Get("pageScale"), 64); err != nil {
+			ctxLogger.Debug("invalid pageScale parameter: " + err.Error())
+			http.Error(w, "invalid pageScale parameter: "+err.Error(), http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	// The readiness parameters take precedence over the override of the dashboard
+	readiness := conf.Readiness(dashboardUID)
+
+	if req.URL.Query().Has("readinessStrategy") {
+		readiness.Strategy = req.URL.Query().Get("readinessStrategy")
+	}
+
+	for param, option := range map[string]*config.Duration{
+		"readinessSettleDelay": &readiness.SettleDelay,
+		"readinessTimeout":     &readiness.Timeout,
+	} {
+		if req.URL.Query().Has(param) {
+			if err = option.UnmarshalText([]byte(req.URL.Query().Get(param))); err != nil {
+				ctxLogger.Debug(fmt.Sprintf("invalid %s parameter: %s", param, err))
+				http.Error(w, fmt.Sprintf("invalid %s parameter: %s", param, err), http.StatusBadRequest)
+
+				return
+			}
+		}
+	}
+
+	if readiness != conf.Readiness(dashboardUID) {
+		conf.ReadinessOverrides = maps.Clone(conf.ReadinessOverrides)
+		if conf.ReadinessOverrides == nil {
+			conf.ReadinessOverrides = make(map[string]config.Readiness, 1)
+		}
+
+		conf.ReadinessOverrides[dashboardUID] = readiness
+	}
+
+	for param, option := range map[string]*config.Duration{
+		"tabTimeout":    &conf.TabTimeout,
+		"renderTimeout": &conf.RenderTimeout,
+		"pdfTimeout":    &conf.PDFTimeout,
+		"reportTimeout": &conf.ReportTimeout,
+	} {
+		if req.URL.Query().Has(param) {
+			if err = option.UnmarshalText([]byte(req.URL.Query().Get(param))); err != nil {
+				ctxLogger.Debug(fmt.Sprintf("invalid %s parameter: %s", param, err))
+				http.Error(w, fmt.Sprintf("invalid %s parameter: %s", param, err), http.StatusBadRequest)
+
+				return
+			}
+		}
+	}
+
 	if req.URL.Query().Has("timeZone") {
 		conf.TimeZone = req.URL.Query().Get("timeZone")
 	}
@@ -127,6 +321,26 @@ func (app *App) handleReport(w http.ResponseWriter, req *http.Request) {
 		}
 	}
 
+	for param, filter := range map[string]*[]string{
+		"includePanelTitle": &conf.IncludePanelTitles,
+		"excludePanelTitle": &conf.ExcludePanelTitles,
+		"includePanelType":  &conf.IncludePanelTypes,
+		"excludePanelType":  &conf.ExcludePanelTypes,
+		"includeRowTitle":   &conf.IncludeRowTitles,
+		"excludeRowTitle":   &conf.ExcludeRowTitles,
+	} {
+		if req.URL.Query().Has(param) {
+			*filter = req.URL.Query()[param]
+		}
+	}
+
+	if err = conf.Validate(); err != nil {
+		ctxLogger.Debug("invalid parameter: " + err.Error())
+		http.Error(w, "invalid parameter: "+err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
 	ctxLogger.Info("generate report using config: " + conf.String())
 
 	var grafanaAppURL string
@@ -176,14 +390,91 @@ func (app *App) handleReport(w http.ResponseWriter, req *http.Request) {
 
 	ctxLogger.Info(fmt.Sprintf("generate report using %s chrome", app.chromeInstance.Name()))
 
+	priority := worker.Interactive
+	if req.URL.Query().Has("priority") {
+		priority = req.URL.Query().Get("priority")
+	}
+
+	if priority != worker.Interactive && priority != worker.Batch {
+		ctxLogger.Debug("invalid priority parameter: " + priority)
+		http.Error(w, "invalid priority parameter: must be one of interactive or batch", http.StatusBadRequest)
+
+		return
+	}
+
+	// The jobs of the report are scheduled fairly between users and orgs
+	tenant := worker.Tenant{User: currentUser, OrgID: pluginConfig.OrgID, Priority: priority}
+	ctx := worker.WithTenant(req.Context(), tenant)
+
+	// Reject reports early, while the workers cannot take any more jobs of the user
+	if app.workerPools.Full(ctx) {
+		reportsTotal.WithLabelValues(reportRejected).Inc()
+		ctxLogger.Warn("report rejected, worker queue is full", "dash_uid", dashboardUID, "workers", app.workerPools.Stats())
+		w.Header().Set("Retry-After", queueRetryAfter)
+		http.Error(w, "too many reports in progress, try again later", http.StatusTooManyRequests)
+
+		return
+	}
+
+	ctxLogger.Debug("scheduling report", "org_id", tenant.OrgID, "priority", tenant.Priority,
+		"workers", app.workerPools.Stats())
+
+	// The overall deadline of the report
+	if conf.ReportTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(conf.ReportTimeout))
+		defer cancel()
+	}
+
+	if debug {
+		app.generateDebugBundle(ctx, ctxLogger, w, pdfReport, dashboardUID, conf)
+
+		return
+	}
+
 	// Generate report
-	if err = pdfReport.Generate(req.Context(), w); err != nil {
+	if err = pdfReport.Generate(ctx, w); err != nil {
+		// The client is gone, so there is nobody to respond to.
+		if errors.Is(err, context.Canceled) && req.Context().Err() != nil {
+			reportsTotal.WithLabelValues(reportCanceled).Inc()
+			ctxLogger.Info("report canceled by client", "dash_uid", dashboardUID, "err", err)
+
+			return
+		}
+
+		if errors.Is(err, worker.ErrQueueFull) {
+			reportsTotal.WithLabelValues(reportRejected).Inc()
+			ctxLogger.Warn("report rejected, worker queue is full", "dash_uid", dashboardUID, "err", err)
+			w.Header().Set("Retry-After", queueRetryAfter)
+			http.Error(w, "too many reports in progress, try again later", http.StatusTooManyRequests)
+
+			return
+		}
+
+		reportsTotal.WithLabelValues(reportFailed).Inc()
+
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+			ctxLogger.Error("report deadline exceeded", "timeout", conf.ReportTimeout, "err", err)
+			http.Error(w, "report deadline exceeded", http.StatusGatewayTimeout)
+
+			return
+		}
+
+		if errors.Is(err, report.ErrUnhealthyPanels) {
+			ctxLogger.Warn("report not generated due to unhealthy panels", "err", err)
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+
+			return
+		}
+
 		ctxLogger.Error("error generating report", "err", err)
 		http.Error(w, "error generating report", http.StatusInternalServerError)
 
 		return
 	}
 
+	reportsTotal.WithLabelValues(reportGenerated).Inc()
 	ctxLogger.Info("report generated", "dash_uid", dashboardUID)
 }
 
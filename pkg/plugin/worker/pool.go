@@ -1,14 +1,94 @@
 package worker
 
 import (
+	"errors"
+	"fmt"
 	"runtime"
+	"sync"
+	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"golang.org/x/net/context"
 )
 
+var (
+	ErrQueueFull  = errors.New("worker queue is full")
+	ErrPoolClosed = errors.New("worker pool is closed")
+)
+
+// Metrics of the worker pools by the name of the pool.
+var (
+	queueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana_plugin",
+		Name:      "reporter_worker_queue_depth",
+		Help:      "Number of jobs waiting for a worker.",
+	}, []string{"pool"})
+
+	busyWorkers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana_plugin",
+		Name:      "reporter_worker_busy",
+		Help:      "Number of workers running a job.",
+	}, []string{"pool"})
+
+	rejectedJobs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana_plugin",
+		Name:      "reporter_worker_rejected_total",
+		Help:      "Number of jobs rejected, because the queue was full.",
+	}, []string{"pool"})
+)
+
+// Options configures the workers and the scheduling of a pool.
+type Options struct {
+	// Workers is the number of workers. If it is not positive, one worker per CPU is used.
+	Workers int
+	// QueueSize is the maximum number of jobs waiting for a worker.
+	QueueSize int
+	// MaxQueuedPerUser is the maximum number of jobs of a user waiting for a worker with
+	// the same priority, so that a single user cannot fill the queue of all users. If it
+	// is not positive or larger than QueueSize, it is QueueSize.
+	MaxQueuedPerUser int
+	// MaxPerUser and MaxPerOrg are the maximum number of jobs run at the same time for
+	// a user and for an org. If they are 0, the number is not limited.
+	MaxPerUser int
+	MaxPerOrg  int
+}
+
+// job is a queued function along with its tenant.
+type job struct {
+	f      func()
+	tenant Tenant
+	queued time.Time
+}
+
+// Pool runs jobs on a fixed number of workers. Jobs wait in a bounded queue, and jobs
+// that do not fit into the queue are rejected.
+//
+// Jobs are scheduled fairly: every tenant has its own queue and the workers take jobs
+// from the tenants in turn, so that a large report does not hold up the reports of
+// other users. Interactive jobs are taken before batch jobs and tenants that reached
+// their concurrency cap are skipped.
 type Pool struct {
-	ctxCancelFunc context.CancelFunc
-	queue         chan func()
+	logger  log.Logger
+	name    string
+	options Options
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	// queues are the queued jobs by tenant and priority
+	queues map[string][]job
+	// ring are the tenants with queued jobs in their turn by priority class
+	ring [2][]string
+	// queued is the number of queued jobs of all tenants
+	queued int
+	// running are the running jobs by tenant and by org
+	running    map[string]int
+	runningOrg map[int64]int
+	busy       int
+	closed     bool
+
+	wg sync.WaitGroup
 }
 
 type Pools map[string]*Pool
@@ -18,34 +98,275 @@ const (
 	Renderer = "renderer"
 )
 
-func New(ctx context.Context, maxWorker int) *Pool {
-	queue := make(chan func(), maxWorker)
-	ctx, cancel := context.WithCancel(ctx)
+// Stats are the size and the load of a pool.
+type Stats struct {
+	Workers    int `json:"workers"`
+	Busy       int `json:"busy"`
+	QueueDepth int `json:"queueDepth"`
+	QueueSize  int `json:"queueSize"`
+	// Tenants is the number of tenants with queued jobs.
+	Tenants int `json:"tenants"`
+}
+
+// New starts a pool with the workers and the queue of the options.
+func New(logger log.Logger, name string, options Options) *Pool {
+	if options.Workers <= 0 {
+		options.Workers = runtime.NumCPU()
+	}
+
+	options.QueueSize = max(options.QueueSize, 1)
+
+	if options.MaxQueuedPerUser <= 0 || options.MaxQueuedPerUser > options.QueueSize {
+		options.MaxQueuedPerUser = options.QueueSize
+	}
 
-	if maxWorker <= 0 {
-		maxWorker = runtime.NumCPU()
+	w := &Pool{
+		logger:     logger.With("pool", name),
+		name:       name,
+		options:    options,
+		queues:     make(map[string][]job),
+		running:    make(map[string]int),
+		runningOrg: make(map[int64]int),
 	}
+	w.cond = sync.NewCond(&w.mu)
+
+	w.wg.Add(options.Workers)
+
+	for range options.Workers {
+		go w.work()
+	}
+
+	return w
+}
+
+// work runs jobs until the pool is closed and drained.
+func (w *Pool) work() {
+	defer w.wg.Done()
 
-	for range maxWorker {
-		go func() {
-			for {
-				select {
-				case f := <-queue:
-					f()
-				case <-ctx.Done():
-					return
-				}
+	for {
+		j, ok := w.next()
+		if !ok {
+			return
+		}
+
+		j.f()
+
+		w.mu.Lock()
+		w.done(j.tenant)
+		w.mu.Unlock()
+	}
+}
+
+// next waits for the next job to run. It returns false once the pool is closed and
+// all jobs are done.
+func (w *Pool) next() (job, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for {
+		if j, ok := w.pick(); ok {
+			return j, true
+		}
+
+		if w.closed && w.queued == 0 {
+			return job{}, false
+		}
+
+		w.cond.Wait()
+	}
+}
+
+// pick takes the next job of the first tenant in turn, which did not reach its cap.
+// Interactive tenants are tried before batch tenants. The tenant goes to the end of
+// the ring, so that the other tenants are served next. The caller must hold the lock.
+func (w *Pool) pick() (job, bool) {
+	for class := range w.ring {
+		for i, queueKey := range w.ring[class] {
+			queue := w.queues[queueKey]
+			tenant := queue[0].tenant
+
+			if !w.available(tenant) {
+				continue
 			}
-		}()
+
+			j := queue[0]
+			w.queues[queueKey] = queue[1:]
+			w.queued--
+
+			// Rotate the ring: the tenant goes to the end or leaves it, if it has no jobs left
+			w.ring[class] = append(w.ring[class][:i:i], w.ring[class][i+1:]...)
+			if len(w.queues[queueKey]) > 0 {
+				w.ring[class] = append(w.ring[class], queueKey)
+			} else {
+				delete(w.queues, queueKey)
+			}
+
+			key := tenant.key()
+			w.running[key]++
+			w.runningOrg[tenant.OrgID]++
+			w.busy++
+			w.updateMetrics()
+
+			w.logger.Debug("scheduled job", "user", tenant.User, "org_id", tenant.OrgID,
+				"priority", tenant.Priority, "waited", time.Since(j.queued),
+				"tenant_queued", len(w.queues[queueKey]), "tenant_running", w.running[key],
+				"tenants_waiting", len(w.ring[0])+len(w.ring[1]))
+
+			return j, true
+		}
+	}
+
+	return job{}, false
+}
+
+// available returns true if the tenant is below the concurrency caps of the pool.
+// The caller must hold the lock.
+func (w *Pool) available(tenant Tenant) bool {
+	if w.options.MaxPerUser > 0 && w.running[tenant.key()] >= w.options.MaxPerUser {
+		return false
+	}
+
+	if w.options.MaxPerOrg > 0 && w.runningOrg[tenant.OrgID] >= w.options.MaxPerOrg {
+		return false
+	}
+
+	return true
+}
+
+// done releases the slot of the job of the tenant. The caller must hold the lock.
+func (w *Pool) done(tenant Tenant) {
+	key := tenant.key()
+
+	if w.running[key]--; w.running[key] == 0 {
+		delete(w.running, key)
 	}
 
-	return &Pool{cancel, queue}
+	if w.runningOrg[tenant.OrgID]--; w.runningOrg[tenant.OrgID] == 0 {
+		delete(w.runningOrg, tenant.OrgID)
+	}
+
+	w.busy--
+	w.updateMetrics()
+
+	// Jobs of the tenant might have been waiting for the slot
+	w.cond.Broadcast()
 }
 
-func (w *Pool) Do(f func()) {
-	w.queue <- f
+// updateMetrics updates the metrics of the pool. The caller must hold the lock.
+func (w *Pool) updateMetrics() {
+	queueDepth.WithLabelValues(w.name).Set(float64(w.queued))
+	busyWorkers.WithLabelValues(w.name).Set(float64(w.busy))
+}
+
+// Do queues f to be run by a worker for the tenant of ctx. It returns an error without
+// running f, if ctx is done, the queue of the pool or of the tenant is full or the pool
+// is closed. Once queued, f is
+// run even if ctx is done in the meantime, so f must check ctx itself.
+func (w *Pool) Do(ctx context.Context, f func()) error {
+	if err := ctx.Err(); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	tenant := tenantFromContext(ctx)
+	queueKey := tenant.queueKey()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return fmt.Errorf("%s: %w", w.name, ErrPoolClosed)
+	}
+
+	if w.full(queueKey) {
+		rejectedJobs.WithLabelValues(w.name).Inc()
+
+		return fmt.Errorf("%s: %w", w.name, ErrQueueFull)
+	}
+
+	if len(w.queues[queueKey]) == 0 {
+		w.ring[tenant.class()] = append(w.ring[tenant.class()], queueKey)
+	}
+
+	w.queues[queueKey] = append(w.queues[queueKey], job{f: f, tenant: tenant, queued: time.Now()})
+	w.queued++
+	w.updateMetrics()
+
+	w.cond.Signal()
+
+	return nil
 }
 
-func (w *Pool) Done() {
-	w.ctxCancelFunc()
+// Full returns true if new jobs of the tenant of ctx are rejected, because the queue of
+// the pool or of the tenant is full.
+func (w *Pool) Full(ctx context.Context) bool {
+	queueKey := tenantFromContext(ctx).queueKey()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.full(queueKey)
+}
+
+// full returns true if the queue of the pool or the queue of the tenant is full. The
+// caller must hold the lock.
+func (w *Pool) full(queueKey string) bool {
+	return w.queued >= w.options.QueueSize || len(w.queues[queueKey]) >= w.options.MaxQueuedPerUser
+}
+
+// Stats returns the size and the load of the pool.
+func (w *Pool) Stats() Stats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return Stats{
+		Workers:    w.options.Workers,
+		Busy:       w.busy,
+		QueueDepth: w.queued,
+		QueueSize:  w.options.QueueSize,
+		Tenants:    len(w.queues),
+	}
+}
+
+// Dispose stops accepting jobs and waits until the queued jobs are done or ctx is done.
+func (w *Pool) Dispose(ctx context.Context) error {
+	w.mu.Lock()
+	w.closed = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+
+	drained := make(chan struct{})
+
+	go func() {
+		w.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("error draining %s pool with %d queued jobs: %w", w.name, w.Stats().QueueDepth, ctx.Err())
+	}
+}
+
+// Full returns true if any of the pools rejects new jobs of the tenant of ctx.
+func (p Pools) Full(ctx context.Context) bool {
+	for _, pool := range p {
+		if pool.Full(ctx) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Stats returns the stats of the pools by their name.
+func (p Pools) Stats() map[string]Stats {
+	stats := make(map[string]Stats, len(p))
+
+	for name, pool := range p {
+		stats[name] = pool.Stats()
+	}
+
+	return stats
 }
@@ -3,9 +3,12 @@ package worker_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/cloudeteer/grafana-pdf-report-app/pkg/plugin/worker"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPool(t *testing.T) {
@@ -13,17 +16,197 @@ func TestPool(t *testing.T) {
 
 	ctx := context.Background()
 
-	pool := worker.New(ctx, 1)
+	pool := worker.New(log.NewNullLogger(), "test", worker.Options{Workers: 1, QueueSize: 10})
 
 	resultCh := make(chan int, 10)
 
 	for i := range 10 {
-		pool.Do(func() {
+		require.NoError(t, pool.Do(ctx, func() {
 			resultCh <- i
-		})
+		}))
 	}
 
 	for i := range 10 {
 		assert.Equal(t, i, <-resultCh)
 	}
 }
+
+func TestPoolWithFullQueue(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	pool := worker.New(log.NewNullLogger(), "test", worker.Options{Workers: 1, QueueSize: 1})
+	started, release := make(chan struct{}), make(chan struct{})
+
+	require.NoError(t, pool.Do(ctx, func() {
+		close(started)
+		<-release
+	}))
+
+	<-started
+
+	require.NoError(t, pool.Do(ctx, func() {}))
+	assert.True(t, pool.Full(ctx))
+	assert.Equal(t, worker.Stats{Workers: 1, Busy: 1, QueueDepth: 1, QueueSize: 1, Tenants: 1}, pool.Stats())
+	assert.ErrorIs(t, pool.Do(ctx, func() {}), worker.ErrQueueFull)
+
+	close(release)
+	require.NoError(t, pool.Dispose(ctx))
+}
+
+func TestPoolWithFullTenantQueue(t *testing.T) {
+	t.Parallel()
+
+	pool := worker.New(log.NewNullLogger(), "test", worker.Options{Workers: 1, QueueSize: 4, MaxQueuedPerUser: 2})
+	alice := worker.WithTenant(context.Background(), worker.Tenant{User: "alice", OrgID: 1})
+	bob := worker.WithTenant(context.Background(), worker.Tenant{User: "bob", OrgID: 1})
+	started, release := make(chan struct{}), make(chan struct{})
+
+	require.NoError(t, pool.Do(alice, func() {
+		close(started)
+		<-release
+	}))
+
+	<-started
+
+	// The queue of alice is saturated, but bob can still queue jobs
+	require.NoError(t, pool.Do(alice, func() {}))
+	require.NoError(t, pool.Do(alice, func() {}))
+	assert.True(t, pool.Full(alice))
+	assert.ErrorIs(t, pool.Do(alice, func() {}), worker.ErrQueueFull)
+
+	assert.False(t, pool.Full(bob))
+	require.NoError(t, pool.Do(bob, func() {}))
+	require.NoError(t, pool.Do(bob, func() {}))
+
+	// Then the queue of the pool is full for everyone
+	assert.True(t, pool.Full(bob))
+	assert.ErrorIs(t, pool.Do(bob, func() {}), worker.ErrQueueFull)
+
+	close(release)
+	require.NoError(t, pool.Dispose(context.Background()))
+}
+
+func TestPoolWithCanceledContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	pool := worker.New(log.NewNullLogger(), "test", worker.Options{Workers: 1, QueueSize: 1})
+
+	assert.ErrorIs(t, pool.Do(ctx, func() { t.Error("job of canceled context must not run") }), context.Canceled)
+}
+
+func TestPoolDispose(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	pool := worker.New(log.NewNullLogger(), "test", worker.Options{Workers: 1, QueueSize: 10})
+	done := make(chan int, 10)
+
+	for i := range 10 {
+		require.NoError(t, pool.Do(ctx, func() {
+			done <- i
+		}))
+	}
+
+	// Queued jobs are drained, but new jobs are rejected
+	require.NoError(t, pool.Dispose(ctx))
+	assert.Len(t, done, 10)
+	assert.ErrorIs(t, pool.Do(ctx, func() {}), worker.ErrPoolClosed)
+}
+
+func TestPoolDisposeWithTimeout(t *testing.T) {
+	t.Parallel()
+
+	pool := worker.New(log.NewNullLogger(), "test", worker.Options{Workers: 1, QueueSize: 1})
+	release := make(chan struct{})
+
+	defer close(release)
+
+	require.NoError(t, pool.Do(context.Background(), func() { <-release }))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, pool.Dispose(ctx), context.DeadlineExceeded)
+}
+
+func TestPoolSchedulesTenantsInTurn(t *testing.T) {
+	t.Parallel()
+
+	pool := worker.New(log.NewNullLogger(), "test", worker.Options{Workers: 1, QueueSize: 10})
+	started, release := make(chan struct{}), make(chan struct{})
+
+	// Block the worker, so that the order of the queued jobs is decided at once
+	require.NoError(t, pool.Do(context.Background(), func() {
+		close(started)
+		<-release
+	}))
+
+	<-started
+
+	resultCh := make(chan string, 10)
+
+	for _, tc := range []struct {
+		user     string
+		priority string
+		job      string
+	}{
+		{"alice", worker.Interactive, "alice-1"},
+		{"alice", worker.Interactive, "alice-2"},
+		{"alice", worker.Interactive, "alice-3"},
+		{"bob", worker.Interactive, "bob-1"},
+		{"carol", worker.Batch, "carol-1"},
+		{"dave", worker.Interactive, "dave-1"},
+	} {
+		ctx := worker.WithTenant(context.Background(), worker.Tenant{User: tc.user, OrgID: 1, Priority: tc.priority})
+
+		require.NoError(t, pool.Do(ctx, func() { resultCh <- tc.job }))
+	}
+
+	close(release)
+	require.NoError(t, pool.Dispose(context.Background()))
+	close(resultCh)
+
+	jobs := make([]string, 0, 6)
+	for job := range resultCh {
+		jobs = append(jobs, job)
+	}
+
+	assert.Equal(t, []string{"alice-1", "bob-1", "dave-1", "alice-2", "alice-3", "carol-1"}, jobs)
+}
+
+func TestPoolWithUserCap(t *testing.T) {
+	t.Parallel()
+
+	pool := worker.New(log.NewNullLogger(), "test", worker.Options{Workers: 2, QueueSize: 10, MaxPerUser: 1})
+	alice := worker.WithTenant(context.Background(), worker.Tenant{User: "alice", OrgID: 1})
+	bob := worker.WithTenant(context.Background(), worker.Tenant{User: "bob", OrgID: 1})
+
+	release := make(chan struct{})
+	resultCh := make(chan string, 3)
+
+	require.NoError(t, pool.Do(alice, func() {
+		resultCh <- "alice-1"
+		<-release
+	}))
+	require.NoError(t, pool.Do(alice, func() { resultCh <- "alice-2" }))
+	require.NoError(t, pool.Do(bob, func() { resultCh <- "bob-1" }))
+
+	// The second job of alice waits for her first job, although a worker is idle
+	assert.ElementsMatch(t, []string{"alice-1", "bob-1"}, []string{<-resultCh, <-resultCh})
+
+	select {
+	case job := <-resultCh:
+		t.Errorf("job %s exceeded the cap of the user", job)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(release)
+	assert.Equal(t, "alice-2", <-resultCh)
+	require.NoError(t, pool.Dispose(context.Background()))
+}
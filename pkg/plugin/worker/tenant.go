@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+)
+
+// Priorities of jobs. Interactive jobs are run before batch jobs.
+const (
+	Interactive = "interactive"
+	Batch       = "batch"
+)
+
+// Tenant is the user and the org a job is run for.
+type Tenant struct {
+	User     string
+	OrgID    int64
+	Priority string
+}
+
+// key identifies the tenant along with its org, as logins are unique per instance,
+// but the same user may work in several orgs.
+func (t Tenant) key() string {
+	return fmt.Sprintf("%d/%s", t.OrgID, t.User)
+}
+
+// queueKey identifies the queue of the tenant for the priority of its jobs.
+func (t Tenant) queueKey() string {
+	return fmt.Sprintf("%s/%d", t.key(), t.class())
+}
+
+// class returns the index of the queues of the priority of the tenant.
+func (t Tenant) class() int {
+	if t.Priority == Batch {
+		return 1
+	}
+
+	return 0
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a context whose jobs are scheduled for the tenant.
+func WithTenant(ctx context.Context, tenant Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// tenantFromContext returns the tenant of the context. Jobs without tenant share an
+// anonymous interactive tenant.
+func tenantFromContext(ctx context.Context) Tenant {
+	tenant, _ := ctx.Value(tenantKey{}).(Tenant)
+
+	return tenant
+}
@@ -583,12 +583,12 @@ export const AppConfig = ({ plugin }: Props) => {
         {/* Remote Chrome URL */}
         <Field
           label="Remote Chrome URL"
-          description="Address to a running chrome instance with an listening chrome remote debug socket"
+          description="Address to a running chrome instance with an listening chrome remote debug socket. Separate multiple addresses by commas to spread the load across them."
           data-testid={testIds.appConfig.remoteChromeUrl}
           className={s.marginTop}
         >
           <Input
-            type="url"
+            type="text"
             width={60}
             id="remoteChromeUrl"
             label={`Remote Chrome URL`}